@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+)
+
+const (
+	soundSampleRate = 44100
+	soundsDir       = "data/sounds"
+)
+
+// SoundPlayer проигрывает короткие звуковые эффекты из data/sounds/*.wav.
+// Отсутствующие звуки молча пропускаются.
+type SoundPlayer struct {
+	context *audio.Context
+	sounds  map[string][]byte
+}
+
+// NewSoundPlayer загружает звуки и возвращает ошибки файлов, которые есть,
+// но не читаются. Звуки не обязательны: игра идет и без них.
+func NewSoundPlayer() (*SoundPlayer, []error) {
+	sp := &SoundPlayer{
+		context: audio.NewContext(soundSampleRate),
+		sounds:  make(map[string][]byte),
+	}
+	return sp, sp.loadSounds()
+}
+
+func (sp *SoundPlayer) loadSounds() []error {
+	files, err := filepath.Glob(filepath.Join(soundsDir, "*.wav"))
+	if err != nil || len(files) == 0 {
+		log.Printf("No sounds found in %s", soundsDir)
+		return nil
+	}
+
+	var errs []error
+
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("Warning: failed to read sound %q: %v", path, err)
+			errs = append(errs, assetError(AssetSound, path, false, err))
+			continue
+		}
+
+		stream, err := wav.DecodeWithSampleRate(soundSampleRate, bytes.NewReader(data))
+		if err != nil {
+			log.Printf("Warning: failed to decode sound %q: %v", path, err)
+			errs = append(errs, assetError(AssetSound, path, false, err))
+			continue
+		}
+
+		pcm, err := io.ReadAll(stream)
+		if err != nil {
+			log.Printf("Warning: failed to decode sound %q: %v", path, err)
+			errs = append(errs, assetError(AssetSound, path, false, err))
+			continue
+		}
+
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		sp.sounds[name] = pcm
+	}
+	return errs
+}
+
+// Play проигрывает звук по имени файла без расширения
+func (sp *SoundPlayer) Play(name string) {
+	if sp == nil {
+		return
+	}
+	pcm, ok := sp.sounds[name]
+	if !ok {
+		return
+	}
+	sp.context.NewPlayerFromBytes(pcm).Play()
+}
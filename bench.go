@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Параметры замера отрисовки тайлов (runTileBenchmark и BenchmarkTileRender*)
+const (
+	benchMapSize      = 256 // Сторона карты в клетках
+	benchTileSize     = 32
+	benchScreenW      = 1280
+	benchScreenH      = 720
+	benchWarmupFrames = 60
+	benchFrames       = 600
+)
+
+// tileBench прокручивает камеру по большой карте и замеряет время кадра
+// в каждом режиме отрисовки тайлов
+type tileBench struct {
+	game  *Game
+	level *Level
+	modes []TileRenderMode
+	mode  int
+	frame int
+	draw  time.Duration // Суммарное время drawTiledLevel в замере
+	start time.Time
+}
+
+// runTileBenchmark сравнивает отрисовку тайлов по одному, с отсечением
+// по камере и из кэша кусков на карте 256x256. Время кадра честное только
+// в работающем игровом цикле, поэтому нужно окно: go run . bench tiles
+func runTileBenchmark() {
+	b := &tileBench{
+		game:  &Game{},
+		level: benchTileLevel(benchMapSize, benchTileSize),
+		modes: []TileRenderMode{TileRenderAll, TileRenderCulled, TileRenderCached},
+	}
+	ebiten.SetWindowSize(benchScreenW, benchScreenH)
+	ebiten.SetWindowTitle("Tile benchmark")
+	ebiten.SetVsyncEnabled(false)
+	ebiten.SetTPS(ebiten.SyncWithFPS)
+	if err := ebiten.RunGame(b); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// benchTileLevel - карта из сплошного слоя земли и редкого слоя декора
+func benchTileLevel(size, tile int) *Level {
+	rng := rand.New(rand.NewSource(1))
+	palette := []color.RGBA{{70, 140, 60, 255}, {90, 160, 70, 255}, {120, 110, 80, 255}, {60, 90, 160, 255}}
+	images := make(map[int]*ebiten.Image, len(palette))
+	for i, c := range palette {
+		img := ebiten.NewImage(tile, tile)
+		img.Fill(c)
+		images[i+1] = img
+	}
+
+	ground := make([]int, size*size)
+	decor := make([]int, size*size)
+	for i := range ground {
+		ground[i] = 1 + rng.Intn(len(palette))
+		if rng.Intn(5) == 0 {
+			decor[i] = 1 + rng.Intn(len(palette))
+		}
+	}
+
+	m := &TiledMap{
+		Width: size, Height: size, TileWidth: tile, TileHeight: tile,
+		Layers: []Layer{
+			{Name: "ground", Type: "tilelayer", Data: ground, Width: size, Height: size, Opacity: 1, Visible: true},
+			{Name: "decor", Type: "tilelayer", Data: decor, Width: size, Height: size, Opacity: 0.8, Visible: true},
+		},
+	}
+	return &Level{Name: "bench", TiledMap: m, TileImages: images, Width: size, Height: size}
+}
+
+// benchCamera ходит по диагонали туда и обратно, чтобы кэш собирал новые куски
+func benchCamera(g *Game, frame int) {
+	world := float64(benchMapSize * benchTileSize)
+	g.camera.Width, g.camera.Height = benchScreenW, benchScreenH
+	t := float64(frame*6) / (world - benchScreenW)
+	t -= 2 * math.Floor(t/2)
+	if t > 1 {
+		t = 2 - t
+	}
+	g.camera.X = t * (world - benchScreenW)
+	g.camera.Y = t * (world - benchScreenH)
+}
+
+func (b *tileBench) Update() error {
+	if b.mode >= len(b.modes) {
+		return ebiten.Termination
+	}
+	return nil
+}
+
+func (b *tileBench) Draw(screen *ebiten.Image) {
+	if b.mode >= len(b.modes) {
+		return
+	}
+	g := b.game
+	g.tileRender = b.modes[b.mode]
+	benchCamera(g, b.frame)
+
+	if b.frame == benchWarmupFrames {
+		b.start = time.Now()
+	}
+	start := time.Now()
+	g.drawTiledLevel(screen, b.level)
+	if b.frame >= benchWarmupFrames {
+		b.draw += time.Since(start)
+	}
+	b.frame++
+
+	// Время кадра включает исполнение команд на GPU и показ кадра
+	if b.frame == benchWarmupFrames+benchFrames {
+		frame := time.Since(b.start) / benchFrames
+		fmt.Printf("%dx%d map, %-9s: draw %10s/frame, frame %10s (%.0f FPS)\n",
+			benchMapSize, benchMapSize, g.tileRender, b.draw/benchFrames, frame, float64(time.Second)/float64(frame))
+		b.mode++
+		b.frame, b.draw = 0, 0
+	}
+}
+
+func (b *tileBench) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return benchScreenW, benchScreenH
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"image"
+	"math"
+)
+
+// Chunk - кусок тайлового слоя бесконечной карты Tiled
+type Chunk struct {
+	Data   []int `json:"data"`
+	X      int   `json:"x"` // Левый верхний угол в тайлах
+	Y      int   `json:"y"`
+	Width  int   `json:"width"`
+	Height int   `json:"height"`
+}
+
+// chunkIndex - поиск куска по координатам тайла. Все куски слоя одного
+// размера и лежат на одной сетке, привязанной к первому куску.
+type chunkIndex struct {
+	origin TilePoint
+	w, h   int
+	at     map[TilePoint]int // Номер куска в Layer.Chunks
+}
+
+func (ci *chunkIndex) key(x, y int) TilePoint {
+	return TilePoint{X: floorDiv(x-ci.origin.X, ci.w), Y: floorDiv(y-ci.origin.Y, ci.h)}
+}
+
+// Chunked сообщает, что слой хранится кусками (бесконечная карта)
+func (l *Layer) Chunked() bool {
+	return len(l.Chunks) > 0
+}
+
+// indexChunks строит индекс кусков. Нужно после загрузки и после замены Chunks.
+func (l *Layer) indexChunks() {
+	if !l.Chunked() {
+		l.chunks = nil
+		return
+	}
+	first := l.Chunks[0]
+	l.chunks = &chunkIndex{
+		origin: TilePoint{X: first.X, Y: first.Y},
+		w:      max(first.Width, 1),
+		h:      max(first.Height, 1),
+		at:     make(map[TilePoint]int, len(l.Chunks)),
+	}
+	for i, c := range l.Chunks {
+		l.chunks.at[l.chunks.key(c.X, c.Y)] = i
+	}
+}
+
+// TileAt возвращает GID тайла (0 - пусто) в клетке (x, y)
+func (l *Layer) TileAt(x, y int) int {
+	if !l.Chunked() {
+		if x < 0 || y < 0 || x >= l.Width || y >= l.Height || y*l.Width+x >= len(l.Data) {
+			return 0
+		}
+		return l.Data[y*l.Width+x]
+	}
+	if l.chunks == nil {
+		return 0
+	}
+	i, ok := l.chunks.at[l.chunks.key(x, y)]
+	if !ok {
+		return 0
+	}
+	c := &l.Chunks[i]
+	idx := (y-c.Y)*c.Width + (x - c.X)
+	if idx < 0 || idx >= len(c.Data) {
+		return 0
+	}
+	return c.Data[idx]
+}
+
+// SetTileAt меняет тайл в клетке (x, y). В слое из кусков недостающий
+// кусок создается. Возвращает false, если клетка вне слоя.
+func (l *Layer) SetTileAt(x, y, gid int) bool {
+	if !l.Chunked() {
+		if x < 0 || y < 0 || x >= l.Width || y >= l.Height || y*l.Width+x >= len(l.Data) {
+			return false
+		}
+		l.Data[y*l.Width+x] = gid
+		return true
+	}
+	if l.chunks == nil {
+		l.indexChunks()
+	}
+	key := l.chunks.key(x, y)
+	i, ok := l.chunks.at[key]
+	if !ok {
+		if gid == 0 {
+			return true
+		}
+		l.Chunks = append(l.Chunks, Chunk{
+			Data:   make([]int, l.chunks.w*l.chunks.h),
+			X:      l.chunks.origin.X + key.X*l.chunks.w,
+			Y:      l.chunks.origin.Y + key.Y*l.chunks.h,
+			Width:  l.chunks.w,
+			Height: l.chunks.h,
+		})
+		i = len(l.Chunks) - 1
+		l.chunks.at[key] = i
+	}
+	c := &l.Chunks[i]
+	idx := (y-c.Y)*c.Width + (x - c.X)
+	if idx < 0 || idx >= len(c.Data) {
+		return false
+	}
+	c.Data[idx] = gid
+	return true
+}
+
+// forEachTile вызывает fn для каждого непустого тайла слоя
+func (l *Layer) forEachTile(fn func(x, y, gid int)) {
+	if !l.Chunked() {
+		for i, gid := range l.Data {
+			if gid != 0 && l.Width > 0 {
+				fn(i%l.Width, i/l.Width, gid)
+			}
+		}
+		return
+	}
+	for _, c := range l.Chunks {
+		for i, gid := range c.Data {
+			if gid != 0 && c.Width > 0 {
+				fn(c.X+i%c.Width, c.Y+i/c.Width, gid)
+			}
+		}
+	}
+}
+
+// chunksIn возвращает номера кусков, задевающих область r (в тайлах)
+func (l *Layer) chunksIn(r image.Rectangle) []int {
+	if l.chunks == nil {
+		return nil
+	}
+	min := l.chunks.key(r.Min.X, r.Min.Y)
+	max := l.chunks.key(r.Max.X-1, r.Max.Y-1)
+	var out []int
+	for y := min.Y; y <= max.Y; y++ {
+		for x := min.X; x <= max.X; x++ {
+			if i, ok := l.chunks.at[TilePoint{X: x, Y: y}]; ok {
+				out = append(out, i)
+			}
+		}
+	}
+	return out
+}
+
+// normalizeInfinite готовит бесконечную карту к игре: сдвигает куски и
+// объекты так, чтобы занятая область начиналась с клетки (0, 0), задает
+// размер карты по этой области и строит индексы кусков
+func normalizeInfinite(m *TiledMap) {
+	if !m.Infinite {
+		return
+	}
+
+	minX, minY := math.MaxInt, math.MaxInt
+	maxX, maxY := math.MinInt, math.MinInt
+	walkLayers(m.Layers, func(l *Layer) {
+		for _, c := range l.Chunks {
+			minX, minY = min(minX, c.X), min(minY, c.Y)
+			maxX, maxY = max(maxX, c.X+c.Width), max(maxY, c.Y+c.Height)
+		}
+	})
+	if minX > maxX {
+		return // Ни одного куска
+	}
+
+	dx, dy := float64(minX*m.TileWidth), float64(minY*m.TileHeight)
+	walkLayers(m.Layers, func(l *Layer) {
+		for i := range l.Chunks {
+			l.Chunks[i].X -= minX
+			l.Chunks[i].Y -= minY
+		}
+		if l.Type == "tilelayer" {
+			l.StartX, l.StartY = 0, 0
+			l.Width, l.Height = maxX-minX, maxY-minY
+		}
+		for i := range l.Objects {
+			l.Objects[i].X -= dx
+			l.Objects[i].Y -= dy
+		}
+		l.indexChunks()
+	})
+	m.Width, m.Height = maxX-minX, maxY-minY
+}
+
+// cloneLayers копирует слои вместе с тайлами, кусками, объектами и
+// дочерними слоями групп
+func cloneLayers(layers []Layer) []Layer {
+	out := make([]Layer, len(layers))
+	for i, layer := range layers {
+		layer.Data = append([]int(nil), layer.Data...)
+		layer.Chunks = copyChunks(layer.Chunks)
+		layer.Objects = append([]Object(nil), layer.Objects...)
+		layer.Layers = cloneLayers(layer.Layers)
+		layer.indexChunks()
+		out[i] = layer
+	}
+	return out
+}
+
+func copyChunks(chunks []Chunk) []Chunk {
+	if chunks == nil {
+		return nil
+	}
+	out := make([]Chunk, len(chunks))
+	for i, c := range chunks {
+		out[i] = c
+		out[i].Data = append([]int(nil), c.Data...)
+	}
+	return out
+}
+
+// walkLayers вызывает fn для каждого слоя, включая слои внутри групп
+func walkLayers(layers []Layer, fn func(l *Layer)) {
+	for i := range layers {
+		fn(&layers[i])
+		if layers[i].Type == "group" {
+			walkLayers(layers[i].Layers, fn)
+		}
+	}
+}
+
+// visibleTiles - клетки слоя, видимые камерой, с запасом в одну клетку.
+// Слева и сверху запас больше, если тайлы тайлсета выступают за клетку.
+func (g *Game) visibleTiles(level *Level, style *layerStyle) image.Rectangle {
+	tw, th := level.TileSize()
+	pad := level.tileCache().pad
+	shift := style.shift(&g.camera)
+	view := Position{X: g.camera.X - shift.X, Y: g.camera.Y - shift.Y}
+	return image.Rect(
+		int(math.Floor((view.X-float64(pad.X))/float64(tw)))-1,
+		int(math.Floor((view.Y-float64(pad.Y))/float64(th)))-1,
+		int(math.Ceil((view.X+g.camera.Width)/float64(tw)))+1,
+		int(math.Ceil((view.Y+g.camera.Height)/float64(th)))+1,
+	)
+}
+
+// forEachVisibleTile вызывает fn для непустых тайлов слоя рядом с камерой.
+// У слоя из кусков обходятся только куски, задевающие экран.
+func (g *Game) forEachVisibleTile(level *Level, layer *styledLayer, fn func(x, y, gid int)) {
+	if g.tileRender == TileRenderAll {
+		layer.forEachTile(fn)
+		return
+	}
+	view := g.visibleTiles(level, &layer.style)
+	if !layer.Chunked() {
+		view = view.Intersect(image.Rect(0, 0, layer.Width, layer.Height))
+		for y := view.Min.Y; y < view.Max.Y; y++ {
+			for x := view.Min.X; x < view.Max.X; x++ {
+				if gid := layer.TileAt(x, y); gid != 0 {
+					fn(x, y, gid)
+				}
+			}
+		}
+		return
+	}
+	for _, i := range layer.chunksIn(view) {
+		c := &layer.Chunks[i]
+		for j, gid := range c.Data {
+			if gid != 0 {
+				fn(c.X+j%c.Width, c.Y+j/c.Width, gid)
+			}
+		}
+	}
+}
+
+// activeArea - область вокруг экрана, в которой на потоковых (бесконечных)
+// картах живут враги. Остальные стоят, пока игрок не подойдет.
+func (g *Game) activeArea() image.Rectangle {
+	view := image.Rect(int(g.camera.X), int(g.camera.Y),
+		int(g.camera.X+g.camera.Width), int(g.camera.Y+g.camera.Height))
+	return view.Inset(-StreamMargin)
+}
+
+// simulated сообщает, что врага нужно обновлять и рисовать
+func (g *Game) simulated(level *Level, e *Enemy) bool {
+	return !level.Streaming || e.GetCollisionRect().Overlaps(g.activeArea())
+}
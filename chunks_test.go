@@ -0,0 +1,134 @@
+package main
+
+import "testing"
+
+// testChunk - кусок 16x16, в каждой клетке которого записан ее номер
+// в мире: tileID(x, y)
+func testChunk(cx, cy int) Chunk {
+	c := Chunk{X: cx, Y: cy, Width: 16, Height: 16, Data: make([]int, 256)}
+	for i := range c.Data {
+		c.Data[i] = tileID(cx+i%16, cy+i/16)
+	}
+	return c
+}
+
+func tileID(x, y int) int {
+	return 1 + (x+100)*1000 + (y + 100)
+}
+
+// testChunkedLayer - три куска вокруг начала координат, правый нижний пропущен
+func testChunkedLayer() Layer {
+	l := Layer{Name: "ground", Type: "tilelayer", Chunks: []Chunk{
+		testChunk(-16, -16), testChunk(0, -16), testChunk(-16, 0),
+	}}
+	l.indexChunks()
+	return l
+}
+
+func TestLayerTileAt(t *testing.T) {
+	l := testChunkedLayer()
+	tests := []struct {
+		x, y int
+		want int
+	}{
+		{-16, -16, tileID(-16, -16)},
+		{-1, -1, tileID(-1, -1)},
+		{15, -1, tileID(15, -1)},
+		{0, -16, tileID(0, -16)},
+		{-16, 15, tileID(-16, 15)},
+		{0, 0, 0},     // Куска нет
+		{-17, 0, 0},   // Левее всех кусков
+		{5, -17, 0},   // Выше всех кусков
+		{16, -1, 0},   // Правее всех кусков
+		{-1, 16, 0},   // Ниже всех кусков
+		{-33, -33, 0}, // Отрицательный номер куска, которого нет
+	}
+	for _, tt := range tests {
+		if got := l.TileAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("TileAt(%d, %d) = %d, want %d", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestLayerSetTileAt(t *testing.T) {
+	tests := []struct {
+		name      string
+		x, y, gid int
+		chunks    int // Кусков после записи
+		cx, cy    int // Угол куска, в который попала клетка
+	}{
+		{"existing negative chunk", -3, -7, 9, 3, -16, -16},
+		{"missing chunk is created", 5, 5, 9, 4, 0, 0},
+		{"missing negative chunk is created", -33, 3, 9, 4, -48, 0},
+		{"clearing a missing chunk creates nothing", -40, -40, 0, 3, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := testChunkedLayer()
+			if !l.SetTileAt(tt.x, tt.y, tt.gid) {
+				t.Fatalf("SetTileAt(%d, %d) = false", tt.x, tt.y)
+			}
+			if len(l.Chunks) != tt.chunks {
+				t.Fatalf("got %d chunks, want %d", len(l.Chunks), tt.chunks)
+			}
+			if got := l.TileAt(tt.x, tt.y); got != tt.gid {
+				t.Errorf("TileAt(%d, %d) = %d, want %d", tt.x, tt.y, got, tt.gid)
+			}
+			if tt.gid != 0 {
+				c := l.Chunks[l.chunks.at[l.chunks.key(tt.x, tt.y)]]
+				if c.X != tt.cx || c.Y != tt.cy {
+					t.Errorf("chunk at (%d, %d), want (%d, %d)", c.X, c.Y, tt.cx, tt.cy)
+				}
+			}
+			// Соседние клетки не задеты
+			if got := l.TileAt(-16, -16); got != tileID(-16, -16) {
+				t.Errorf("TileAt(-16, -16) = %d after the write", got)
+			}
+		})
+	}
+}
+
+func TestNormalizeInfinite(t *testing.T) {
+	m := &TiledMap{
+		TileWidth: 32, TileHeight: 32, Infinite: true,
+		Layers: []Layer{
+			{Name: "ground", Type: "tilelayer", StartX: -32, StartY: -16, Chunks: []Chunk{testChunk(-32, -16), testChunk(0, 0)}},
+			{Name: "group", Type: "group", Layers: []Layer{
+				{Name: "decor", Type: "tilelayer", Chunks: []Chunk{testChunk(16, 16)}},
+				{Name: "objects", Type: "objectgroup", Objects: []Object{{Id: 1, X: -100, Y: 50}}},
+			}},
+		},
+	}
+	normalizeInfinite(m)
+
+	// Занятая область - от (-32, -16) до (32, 32)
+	if m.Width != 64 || m.Height != 48 {
+		t.Errorf("map size = %dx%d, want 64x48", m.Width, m.Height)
+	}
+	ground := &m.Layers[0]
+	if ground.Width != 64 || ground.Height != 48 || ground.StartX != 0 || ground.StartY != 0 {
+		t.Errorf("ground layer = %dx%d at (%d, %d), want 64x48 at (0, 0)",
+			ground.Width, ground.Height, ground.StartX, ground.StartY)
+	}
+
+	tests := []struct {
+		layer      *Layer
+		x, y, want int
+	}{
+		{ground, 0, 0, tileID(-32, -16)},
+		{ground, 15, 15, tileID(-17, -1)},
+		{ground, 32, 16, tileID(0, 0)},
+		{ground, 16, 0, 0},
+		{&m.Layers[1].Layers[0], 48, 32, tileID(16, 16)},
+	}
+	for _, tt := range tests {
+		if got := tt.layer.TileAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("%s: TileAt(%d, %d) = %d, want %d", tt.layer.Name, tt.x, tt.y, got, tt.want)
+		}
+	}
+
+	obj := m.Layers[1].Layers[1].Objects[0]
+	if obj.X != -100+32*32 || obj.Y != 50+16*32 {
+		t.Errorf("object moved to (%.0f, %.0f), want (%d, %d)", obj.X, obj.Y, -100+32*32, 50+16*32)
+	}
+}
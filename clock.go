@@ -0,0 +1,79 @@
+package main
+
+import "time"
+
+// Clock - игровое время, которое стоит на месте во время паузы.
+// Все игровые таймеры (неуязвимость, кулдауны, анимации) должны
+// использовать gameClock вместо time.Now.
+type Clock struct {
+	offset   time.Duration // Суммарное время, проведенное на паузе
+	paused   bool
+	pausedAt time.Time
+
+	manual bool      // Время идет только через Advance (прогон без окна)
+	wall   time.Time // Текущее время в ручном режиме
+}
+
+var gameClock = &Clock{}
+
+// Now возвращает текущее игровое время
+func (c *Clock) Now() time.Time {
+	if c.paused {
+		return c.pausedAt.Add(-c.offset)
+	}
+	return c.Wall().Add(-c.offset)
+}
+
+// Wall возвращает время, которое идет и на паузе (для переходов между
+// сценами). В ручном режиме оно тоже двигается только через Advance.
+func (c *Clock) Wall() time.Time {
+	if c.manual {
+		return c.wall
+	}
+	return time.Now()
+}
+
+// SetManual переводит часы в ручной режим: время стоит, пока его не
+// сдвинет Advance. Нужно прогону без окна, где тики идут без задержек.
+func (c *Clock) SetManual() {
+	if c.manual {
+		return
+	}
+	c.wall = time.Now()
+	c.manual = true
+}
+
+// Advance сдвигает время в ручном режиме
+func (c *Clock) Advance(d time.Duration) {
+	if c.manual {
+		c.wall = c.wall.Add(d)
+	}
+}
+
+// Since возвращает игровое время, прошедшее с t
+func (c *Clock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Pause останавливает игровое время
+func (c *Clock) Pause() {
+	if c.paused {
+		return
+	}
+	c.paused = true
+	c.pausedAt = c.Wall()
+}
+
+// Resume продолжает игровое время с того же момента
+func (c *Clock) Resume() {
+	if !c.paused {
+		return
+	}
+	c.offset += c.Wall().Sub(c.pausedAt)
+	c.paused = false
+}
+
+// Paused сообщает, стоит ли время
+func (c *Clock) Paused() bool {
+	return c.paused
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Config - параметры запуска из командной строки
+type Config struct {
+	StartLevel int    // Индекс стартового уровня
+	MapFile    string // Карта Tiled вместо стандартного набора уровней
+	Seed       int64  // Зерно генератора случайных чисел (0 - по времени)
+	Debug      bool   // Отладочная информация на экране
+	Fullscreen bool
+	TPS        int  // Тиков в секунду
+	GodMode    bool // Игрок не получает урон
+	Ticks      int  // Выполнить N тиков без окна и выйти
+	Dev        bool // Режим разработки: горячая перезагрузка карт и спрайтов
+	Strict     bool // Не запускаться без обязательных ресурсов
+}
+
+// parseFlags разбирает аргументы командной строки (без имени программы)
+func parseFlags(args []string, output io.Writer) (*Config, error) {
+	cfg := &Config{}
+
+	fs := flag.NewFlagSet("game", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.IntVar(&cfg.StartLevel, "level", 0, "index of the starting level")
+	fs.StringVar(&cfg.MapFile, "map", "", "load this Tiled JSON map instead of the built-in levels")
+	fs.Int64Var(&cfg.Seed, "seed", 0, "random seed (0 picks one from the clock)")
+	fs.BoolVar(&cfg.Debug, "debug", false, "show the debug overlay")
+	fs.BoolVar(&cfg.Fullscreen, "fullscreen", false, "start in fullscreen instead of a window")
+	fs.IntVar(&cfg.TPS, "tps", 60, "simulation ticks per second")
+	fs.BoolVar(&cfg.GodMode, "god", false, "player takes no damage")
+	fs.IntVar(&cfg.Ticks, "ticks", 0, "run N ticks headless and exit (for scripts and CI)")
+	fs.BoolVar(&cfg.Dev, "dev", false, "development mode: reload changed maps, tilesets and sprites")
+	fs.BoolVar(&cfg.Strict, "strict", false, "refuse to start if a required map or sprite fails to load")
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage: game [flags]\n       game bench tiles\n       game generate [-kind forest|dungeon] [-seed N] [-width W] [-height H] [-density D] [-out file.json]\n       game validate [map.json ...]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	if cfg.TPS <= 0 {
+		return nil, fmt.Errorf("-tps must be positive, got %d", cfg.TPS)
+	}
+	if cfg.StartLevel < 0 {
+		return nil, fmt.Errorf("-level must not be negative, got %d", cfg.StartLevel)
+	}
+	if cfg.Ticks < 0 {
+		return nil, fmt.Errorf("-ticks must not be negative, got %d", cfg.Ticks)
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+	return cfg, nil
+}
+
+// Headless сообщает, что игра запущена без окна
+func (c *Config) Headless() bool {
+	return c.Ticks > 0
+}
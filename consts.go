@@ -3,21 +3,18 @@ package main
 import "time"
 
 const (
-	MaxAngle                       = 256
-	MaxLean                        = 16
-	WinWidth                       = 1920
-	WinHeight                      = 1080
-	CharScale                      = 0.15
-	MoveSpeed                      = 3.0
-	RotationSpeed                  = 1
-	AnimationFPS                   = 10
-	AttackFPS                      = 15
-	AttackCooldown                 = 500 * time.Millisecond
-	PlayerInvulnDuration           = 2 * time.Second        // Длительность неуязвимости
-	PlayerBlinkInterval            = 100 * time.Millisecond // Интервал мигания
-	StateMainMenu        GameState = iota
-	StatePlaying
-	StateGameOver
+	MaxAngle             = 256
+	MaxLean              = 16
+	WinWidth             = 1920
+	WinHeight            = 1080
+	CharScale            = 0.15
+	MoveSpeed            = 3.0
+	RotationSpeed        = 1
+	AnimationFPS         = 10
+	AttackFPS            = 15
+	AttackCooldown       = 500 * time.Millisecond
+	PlayerInvulnDuration = 2 * time.Second        // Длительность неуязвимости
+	PlayerBlinkInterval  = 100 * time.Millisecond // Интервал мигания
 	// Размеры спрайтов
 	SpriteWidth       = 64
 	SpriteHeight      = 64
@@ -29,4 +26,96 @@ const (
 	EnemyHitboxReduction  = 2 // На сколько уменьшаем хитбокс врага
 )
 
-type GameState int
+// Отображение направления взгляда
+const (
+	FacingSectors         = 8   // Количество направлений спрайта (4 или 8)
+	MaxLeanTilt           = 0.2 // Максимальный наклон спрайта в радианах
+	FacingIndicatorLength = 24  // Длина индикатора направления
+)
+
+// Поиск путей и ИИ врагов
+const (
+	PathCacheSize       = 256                    // Максимум путей в кэше
+	NavChunkSize        = 16                     // Сторона куска сетки проходимости бесконечной карты
+	EnemyAggroRange     = 400.0                  // Дистанция, с которой враг начинает преследование
+	EnemyRepathInterval = 500 * time.Millisecond // Как часто враг пересчитывает путь
+	EnemyRepathJitter   = 0.25                   // Разброс интервала, чтобы враги не считали пути в один тик
+	WaypointReachRadius = 4.0                    // Расстояние, на котором точка пути считается достигнутой
+	SpatialCellSize     = 128                    // Размер клетки пространственного индекса
+)
+
+// Урон
+const (
+	ContactDamageCooldown = 1 * time.Second        // Кулдаун урона от касания одного врага
+	ContactKnockback      = 8.0                    // Начальная скорость отбрасывания при касании
+	HazardDamageInterval  = 500 * time.Millisecond // Интервал урона от опасной местности
+	DamagePopupDuration   = 800 * time.Millisecond // Время показа всплывающего урона
+)
+
+// Добыча с погибших врагов
+const (
+	LootHeartChance = 0.3 // Шанс выпадения сердца
+	LootHeartHeal   = 20  // Сколько здоровья восстанавливает сердце
+)
+
+// Сколько сообщение триггера держится после выхода из зоны
+const TriggerMessageDuration = 3 * time.Second
+
+// StreamMargin - запас в пикселях вокруг экрана, в котором на бесконечных
+// картах обновляются враги
+const StreamMargin = 512
+
+// Кэш тайловых слоев (см. TileCache)
+const (
+	TileCacheChunk       = 16  // Сторона куска в клетках
+	TileCacheKeepFrames  = 300 // Сколько кадров хранить невидимый кусок
+	TileCacheSweepFrames = 60  // Как часто искать устаревшие куски
+)
+
+// Местность (см. Terrain)
+const (
+	SlipperyFriction = 0.15                   // Трение на льду, если не задано свойством "friction"
+	SlipperyGrip     = 0.3                    // Доля разгона на льду
+	SwimDepth        = 0.4                    // Доля спрайта под водой при плавании
+	FootstepInterval = 350 * time.Millisecond // Пауза между звуками шагов
+	FootstepMinSpeed = 0.5                    // Скорость, с которой слышны шаги
+)
+
+// Освещение и смена дня и ночи
+const (
+	DayLength            = 10 * time.Minute // Длина игровых суток
+	DayStartHour         = 8                // Час, с которого начинается игра
+	NightThreshold       = 0.5              // Средний общий свет, ниже которого ночь
+	PlayerLightRadius    = 160.0
+	TorchLightRadius     = 240.0
+	LightRays            = 96   // Лучей на источник для теней от стен
+	LightWallPenetration = 12.0 // Насколько свет заходит в стену
+	LightFlicker         = 0.06 // Амплитуда дрожания пламени
+	NightAggroScale      = 1.5  // Дальность обнаружения ночных врагов ночью
+	NightSpeedScale      = 1.2  // Скорость ночных врагов ночью
+	NightSightScale      = 0.6  // Дальность обнаружения дневных врагов ночью вне света
+	LitRadiusFraction    = 0.6  // Доля радиуса источника, внутри которой точка освещена
+)
+
+// Физика движения
+const (
+	BodyAccelerationFactor = 0.35 // Ускорение как доля максимальной скорости за тик
+	BodyFriction           = 0.2  // Доля скорости, теряемая за тик
+	BodyRestSpeed          = 0.05 // Скорость, ниже которой тело останавливается
+	KnockbackDamping       = 0.2  // Затухание отбрасывания за тик
+)
+
+// Переходы между сценами
+const TransitionDuration = 600 * time.Millisecond
+
+// Расстояние, с которого игрок открывает дверь ключом
+const DoorReach = 6
+
+// Редактор уровней
+const (
+	EditorCameraSpeed = 12.0 // Скорость камеры редактора в пикселях за тик
+	EditorUndoLimit   = 100  // Максимум шагов отмены
+)
+
+// Интервал опроса файлов при горячей перезагрузке (-dev)
+const HotReloadInterval = 500 * time.Millisecond
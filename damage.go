@@ -0,0 +1,159 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// DamageType - тип урона, к которому у цели может быть сопротивление
+type DamageType int
+
+const (
+	DamagePhysical DamageType = iota
+	DamageFire
+	DamagePoison
+	DamageCold
+)
+
+func (t DamageType) String() string {
+	switch t {
+	case DamageFire:
+		return "fire"
+	case DamagePoison:
+		return "poison"
+	case DamageCold:
+		return "cold"
+	default:
+		return "physical"
+	}
+}
+
+// parseDamageType переводит строковое значение из свойств Tiled в DamageType
+func parseDamageType(s string) DamageType {
+	switch s {
+	case "fire":
+		return DamageFire
+	case "poison":
+		return DamagePoison
+	case "cold":
+		return DamageCold
+	default:
+		return DamagePhysical
+	}
+}
+
+// DamageSourceKind - откуда пришел урон
+type DamageSourceKind int
+
+const (
+	SourceContact DamageSourceKind = iota
+	SourceProjectile
+	SourceHazard
+	SourceZone // Триггер damage_zone
+	SourceDebug
+)
+
+// DamageSource описывает один удар. ID вместе с Kind определяет
+// источник для собственного кулдауна (индекс врага, снаряда или тип
+// урона местности).
+type DamageSource struct {
+	Kind      DamageSourceKind
+	ID        int
+	Amount    int
+	Type      DamageType
+	Origin    Position      // Откуда пришел удар (для отбрасывания)
+	Knockback float64       // Сила отбрасывания
+	Cooldown  time.Duration // Минимальный интервал между ударами этого источника
+
+	// Урон от местности идет мимо неуязвимости после удара
+	IgnoreInvulnerability bool
+}
+
+// DamageEvent - результат примененного урона
+type DamageEvent struct {
+	Source    DamageSource
+	Amount    int      // Урон после сопротивлений
+	Knockback Position // Вектор отбрасывания
+	Killed    bool
+	Time      time.Time
+}
+
+type damageKey struct {
+	kind DamageSourceKind
+	id   int
+}
+
+// DamageSystem - единая точка нанесения урона игроку.
+// Подписчики (HUD, звук) получают DamageEvent для каждого удара.
+type DamageSystem struct {
+	lastHit   map[damageKey]time.Time
+	listeners []func(DamageEvent)
+}
+
+func NewDamageSystem() *DamageSystem {
+	return &DamageSystem{
+		lastHit: make(map[damageKey]time.Time),
+	}
+}
+
+// Subscribe добавляет обработчик событий урона
+func (d *DamageSystem) Subscribe(fn func(DamageEvent)) {
+	d.listeners = append(d.listeners, fn)
+}
+
+// Reset сбрасывает кулдауны источников (при рестарте или смене уровня)
+func (d *DamageSystem) Reset() {
+	d.lastHit = make(map[damageKey]time.Time)
+}
+
+// Apply наносит урон игроку, если источник не на кулдауне.
+// Возвращает true, если урон прошел.
+func (d *DamageSystem) Apply(p *Player, src DamageSource) bool {
+	if p == nil || p.health <= 0 || p.godMode {
+		return false
+	}
+	if p.invulnerable && !src.IgnoreInvulnerability {
+		return false
+	}
+
+	key := damageKey{src.Kind, src.ID}
+	now := gameClock.Now()
+	if last, ok := d.lastHit[key]; ok && now.Sub(last) < src.Cooldown {
+		return false
+	}
+
+	amount := p.ResistedDamage(src.Type, src.Amount)
+	if amount <= 0 {
+		return false
+	}
+	d.lastHit[key] = now
+
+	knockback := knockbackVector(src.Origin, p.Center(), src.Knockback)
+	p.applyDamage(amount, !src.IgnoreInvulnerability)
+	p.ApplyKnockback(knockback)
+
+	event := DamageEvent{
+		Source:    src,
+		Amount:    amount,
+		Knockback: knockback,
+		Killed:    p.health <= 0,
+		Time:      now,
+	}
+	for _, fn := range d.listeners {
+		fn(event)
+	}
+	return true
+}
+
+// knockbackVector направлен от источника к цели
+func knockbackVector(from, to Position, strength float64) Position {
+	if strength == 0 {
+		return Position{}
+	}
+	dx, dy := to.X-from.X, to.Y-from.Y
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return Position{}
+	}
+	return Position{X: dx / length * strength, Y: dy / length * strength}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// damageStep - удар через wait после предыдущего и ожидаемый результат
+type damageStep struct {
+	wait    time.Duration
+	src     DamageSource
+	applied bool
+}
+
+func contactHit(id int) DamageSource {
+	return DamageSource{Kind: SourceContact, ID: id, Amount: 10, Cooldown: ContactDamageCooldown}
+}
+
+func hazardHit(dtype DamageType) DamageSource {
+	return DamageSource{
+		Kind: SourceHazard, ID: int(dtype), Amount: 5, Type: dtype,
+		Cooldown: HazardDamageInterval, IgnoreInvulnerability: true,
+	}
+}
+
+func TestDamageSystemCooldowns(t *testing.T) {
+	tests := []struct {
+		name       string
+		keepInvuln bool // Не снимать неуязвимость после удара
+		steps      []damageStep
+	}{
+		{
+			name: "same source waits for its cooldown",
+			steps: []damageStep{
+				{0, contactHit(1), true},
+				{ContactDamageCooldown / 2, contactHit(1), false},
+				{ContactDamageCooldown / 2, contactHit(1), true},
+			},
+		},
+		{
+			name: "sources have their own cooldowns",
+			steps: []damageStep{
+				{0, contactHit(1), true},
+				{0, contactHit(2), true},
+				{0, hazardHit(DamageFire), true},
+				{0, contactHit(1), false},
+			},
+		},
+		{
+			name: "kind is part of the source",
+			steps: []damageStep{
+				{0, DamageSource{Kind: SourceContact, ID: 1, Amount: 10, Cooldown: time.Second}, true},
+				{0, DamageSource{Kind: SourceZone, ID: 1, Amount: 10, Cooldown: time.Second}, true},
+			},
+		},
+		{
+			name:       "invulnerability blocks contact but not hazards",
+			keepInvuln: true,
+			steps: []damageStep{
+				{0, contactHit(1), true},
+				{0, contactHit(2), false},
+				{0, hazardHit(DamageFire), true},
+			},
+		},
+		{
+			name: "hazards of one damage type share a cooldown",
+			steps: []damageStep{
+				{0, hazardHit(DamageFire), true},
+				{HazardDamageInterval / 2, hazardHit(DamageFire), false},
+				{0, hazardHit(DamageCold), true},
+				{HazardDamageInterval / 2, hazardHit(DamageFire), true},
+			},
+		},
+	}
+
+	gameClock.SetManual()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDamageSystem()
+			p := NewPlayer()
+			events := 0
+			d.Subscribe(func(DamageEvent) { events++ })
+
+			want, hits := p.health, 0
+			for i, step := range tt.steps {
+				gameClock.Advance(step.wait)
+				if got := d.Apply(p, step.src); got != step.applied {
+					t.Fatalf("step %d: Apply = %v, want %v", i, got, step.applied)
+				}
+				if step.applied {
+					want -= p.ResistedDamage(step.src.Type, step.src.Amount)
+					hits++
+				}
+				if !tt.keepInvuln {
+					p.invulnerable = false
+				}
+			}
+			if p.health != want {
+				t.Errorf("health = %d, want %d", p.health, want)
+			}
+			if events != hits {
+				t.Errorf("got %d events, want %d", events, hits)
+			}
+		})
+	}
+}
+
+func TestDamageSystemIgnoresBlockedHits(t *testing.T) {
+	gameClock.SetManual()
+	d := NewDamageSystem()
+	p := NewPlayer()
+
+	// Полностью поглощенный удар не запускает кулдаун
+	p.resistances[DamageCold] = 1
+	src := contactHit(1)
+	src.Type = DamageCold
+	if d.Apply(p, src) {
+		t.Fatal("fully resisted hit was applied")
+	}
+	delete(p.resistances, DamageCold)
+	if !d.Apply(p, src) {
+		t.Error("hit after a resisted one is on cooldown")
+	}
+
+	// Reset забывает кулдауны
+	p.invulnerable = false
+	d.Reset()
+	if !d.Apply(p, src) {
+		t.Error("hit after Reset is on cooldown")
+	}
+
+	// Режим бога и мертвый игрок
+	p.invulnerable = false
+	p.godMode = true
+	if d.Apply(p, hazardHit(DamageFire)) {
+		t.Error("god mode player took damage")
+	}
+	p.godMode = false
+	p.health = 0
+	if d.Apply(p, hazardHit(DamageCold)) {
+		t.Error("dead player took damage")
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Resolution - логическое разрешение, в котором рисуется игра
+type Resolution struct {
+	Width, Height int
+}
+
+func (r Resolution) String() string {
+	return fmt.Sprintf("%dx%d", r.Width, r.Height)
+}
+
+// Доступные разрешения рендеринга
+var Resolutions = []Resolution{
+	{WinWidth, WinHeight},
+	{1280, 720},
+	{960, 540},
+	{640, 360},
+	{1440, 1080}, // 4:3
+	{2560, 1080}, // 21:9
+}
+
+// Display рисует игру в холст выбранного разрешения и выводит его
+// в окно с сохранением пропорций (полосы по краям).
+type Display struct {
+	resolution   int  // Индекс в Resolutions
+	pixelPerfect bool // Только целочисленное масштабирование
+	canvas       *ebiten.Image
+
+	// Прямоугольник холста внутри окна после последнего Present
+	offsetX, offsetY, scale float64
+}
+
+func NewDisplay() *Display {
+	return &Display{scale: 1}
+}
+
+// Resolution возвращает текущее логическое разрешение
+func (d *Display) Resolution() Resolution {
+	return Resolutions[d.resolution]
+}
+
+// Width и Height - размеры логического экрана для HUD и меню
+func (d *Display) Width() int  { return d.Resolution().Width }
+func (d *Display) Height() int { return d.Resolution().Height }
+
+// NextResolution переключает разрешение на следующее из списка
+func (d *Display) NextResolution() {
+	d.resolution = (d.resolution + 1) % len(Resolutions)
+}
+
+// SetResolution выбирает разрешение из списка по размерам
+func (d *Display) SetResolution(w, h int) bool {
+	for i, r := range Resolutions {
+		if r.Width == w && r.Height == h {
+			d.resolution = i
+			return true
+		}
+	}
+	return false
+}
+
+// TogglePixelPerfect включает или выключает целочисленное масштабирование
+func (d *Display) TogglePixelPerfect() {
+	d.pixelPerfect = !d.pixelPerfect
+}
+
+// ToggleFullscreen переключает полноэкранный режим
+func (d *Display) ToggleFullscreen() {
+	ebiten.SetFullscreen(!ebiten.IsFullscreen())
+}
+
+// Canvas возвращает очищенный холст текущего разрешения
+func (d *Display) Canvas() *ebiten.Image {
+	r := d.Resolution()
+	if d.canvas == nil || d.canvas.Bounds().Dx() != r.Width || d.canvas.Bounds().Dy() != r.Height {
+		d.canvas = ebiten.NewImage(r.Width, r.Height)
+	}
+	d.canvas.Clear()
+	return d.canvas
+}
+
+// Present выводит холст в окно по центру с полосами по краям
+func (d *Display) Present(screen, canvas *ebiten.Image) {
+	screen.Fill(color.Black)
+
+	sw := float64(screen.Bounds().Dx())
+	sh := float64(screen.Bounds().Dy())
+	cw := float64(canvas.Bounds().Dx())
+	ch := float64(canvas.Bounds().Dy())
+
+	scale := math.Min(sw/cw, sh/ch)
+	if d.pixelPerfect && scale >= 1 {
+		scale = math.Floor(scale)
+	}
+
+	d.scale = scale
+	d.offsetX = math.Floor((sw - cw*scale) / 2)
+	d.offsetY = math.Floor((sh - ch*scale) / 2)
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(d.offsetX, d.offsetY)
+	if d.pixelPerfect {
+		op.Filter = ebiten.FilterNearest
+	} else {
+		op.Filter = ebiten.FilterLinear
+	}
+	screen.DrawImage(canvas, op)
+}
+
+// CursorPosition возвращает позицию курсора в координатах холста
+func (d *Display) CursorPosition() (float64, float64) {
+	x, y := ebiten.CursorPosition()
+	return (float64(x) - d.offsetX) / d.scale, (float64(y) - d.offsetY) / d.scale
+}
+
+// Anchor - край логического экрана, к которому привязан элемент HUD
+type Anchor int
+
+const (
+	AnchorTopLeft Anchor = iota
+	AnchorTop
+	AnchorTopRight
+	AnchorLeft
+	AnchorCenter
+	AnchorRight
+	AnchorBottomLeft
+	AnchorBottom
+	AnchorBottomRight
+)
+
+// Anchor переводит смещение от края экрана в координаты холста.
+// Положительные dx, dy всегда направлены внутрь экрана.
+func (d *Display) Anchor(a Anchor, dx, dy float64) (float64, float64) {
+	w, h := float64(d.Width()), float64(d.Height())
+
+	var x, y float64
+	switch a % 3 {
+	case 0:
+		x = dx
+	case 1:
+		x = w/2 + dx
+	case 2:
+		x = w - dx
+	}
+	switch a / 3 {
+	case 0:
+		y = dy
+	case 1:
+		y = h/2 + dy
+	case 2:
+		y = h - dy
+	}
+	return x, y
+}
+
+// Camera - видимая область мира на логическом экране
+type Camera struct {
+	X, Y          float64 // Левый верхний угол в мировых координатах
+	Width, Height float64
+}
+
+// Follow центрирует камеру на target, не выходя за границы мира.
+// Если мир меньше экрана, он располагается по центру.
+func (c *Camera) Follow(target Position, worldW, worldH float64, viewW, viewH int) {
+	c.Width, c.Height = float64(viewW), float64(viewH)
+	c.X = followAxis(target.X, worldW, c.Width)
+	c.Y = followAxis(target.Y, worldH, c.Height)
+}
+
+func followAxis(target, world, view float64) float64 {
+	if world <= view {
+		return -(view - world) / 2
+	}
+	return clampFloat(target-view/2, 0, world-view)
+}
+
+// Apply добавляет смещение камеры к трансформации спрайта в мировых координатах
+func (c *Camera) Apply(op *ebiten.DrawImageOptions) {
+	op.GeoM.Translate(-math.Round(c.X), -math.Round(c.Y))
+}
+
+// ToScreen переводит мировые координаты в координаты холста
+func (c *Camera) ToScreen(p Position) Position {
+	return Position{X: p.X - math.Round(c.X), Y: p.Y - math.Round(c.Y)}
+}
+
+// ToWorld переводит координаты холста в мировые
+func (c *Camera) ToWorld(p Position) Position {
+	return Position{X: p.X + math.Round(c.X), Y: p.Y + math.Round(c.Y)}
+}
@@ -0,0 +1,356 @@
+package main
+
+import "image/color"
+
+// DungeonParams - параметры генерации подземелья
+type DungeonParams struct {
+	Width, Height  int
+	Seed           int64
+	MinRoomSize    int // Минимальная сторона комнаты (без стен)
+	MaxRoomSize    int
+	EnemiesPerRoom int // Максимум обычных врагов в комнате
+}
+
+func DefaultDungeonParams(seed int64) DungeonParams {
+	return DungeonParams{
+		Width:          48,
+		Height:         36,
+		Seed:           seed,
+		MinRoomSize:    4,
+		MaxRoomSize:    10,
+		EnemiesPerRoom: 2,
+	}
+}
+
+// Ключ от двери комнаты босса
+const bossKeyID = 1
+
+// Room - прямоугольник пола комнаты в клетках
+type Room struct {
+	X, Y, W, H int
+}
+
+func (r Room) Center() TilePoint {
+	return TilePoint{X: r.X + r.W/2, Y: r.Y + r.H/2}
+}
+
+// randomTile возвращает случайную клетку пола внутри комнаты
+func (r Room) randomTile(rng *RandStream) TilePoint {
+	return TilePoint{X: r.X + rng.Intn(r.W), Y: r.Y + rng.Intn(r.H)}
+}
+
+// bspNode - узел двоичного разбиения карты
+type bspNode struct {
+	x, y, w, h  int
+	left, right *bspNode
+	room        *Room
+}
+
+// GenerateDungeon создает подземелье разбиением пространства (BSP):
+// комнаты в листьях, коридоры между соседними поддеревьями, стартовая
+// комната, комната босса за запертой дверью и ключ от нее.
+func GenerateDungeon(p DungeonParams) Level {
+	rng := NewRNG(p.Seed).Level
+
+	tiles := make([][]TileType, p.Height)
+	for y := range tiles {
+		tiles[y] = make([]TileType, p.Width)
+		for x := range tiles[y] {
+			tiles[y][x] = TileWall
+		}
+	}
+
+	// Внешний ряд клеток всегда остается стеной
+	root := &bspNode{x: 1, y: 1, w: p.Width - 2, h: p.Height - 2}
+	root.split(rng, p.MinRoomSize+2)
+
+	var rooms []Room
+	root.placeRooms(rng, p, &rooms)
+	for _, r := range rooms {
+		for y := r.Y; y < r.Y+r.H; y++ {
+			for x := r.X; x < r.X+r.W; x++ {
+				tiles[y][x] = TileFloor
+			}
+		}
+	}
+	root.connect(tiles, rng)
+
+	// Старт в первой комнате, босс в самой дальней от нее. Если комната
+	// одна (маленькая карта), босса и запертой двери нет, выход - в ней же.
+	start := rooms[0]
+	bossIndex, exitRoom := -1, start
+	if len(rooms) > 1 {
+		bossIndex = farthestRoom(tiles, rooms, start.Center())
+		exitRoom = rooms[bossIndex]
+	}
+
+	l := Level{
+		Name:          "Dungeon",
+		Map:           tiles,
+		StartPosition: tileTopLeft(start.Center()),
+		ExitPosition:  tileTopLeft(TilePoint{X: exitRoom.X + exitRoom.W - 1, Y: exitRoom.Y + exitRoom.H - 1}),
+		Background:    tileColors[TileWall],
+		Width:         p.Width,
+		Height:        p.Height,
+		Dark:          true,
+	}
+
+	// Факел в каждой комнате
+	for _, r := range rooms {
+		pos := tileTopLeft(r.Center())
+		l.Lights = append(l.Lights, Light{
+			Position: Position{X: pos.X + tileSize/2, Y: pos.Y + tileSize/2},
+			Radius:   TorchLightRadius,
+			Color:    torchLightColor,
+			Flicker:  true,
+		})
+	}
+
+	if bossIndex >= 0 {
+		l.Doors = lockRoom(tiles, exitRoom, bossKeyID)
+		keyRoom := pickKeyRoom(tiles, rooms, l.Doors, 0, bossIndex, rng)
+		l.Keys = []Key{{ID: bossKeyID, Position: tileTopLeft(rooms[keyRoom].randomTile(rng))}}
+	}
+
+	l.Enemies = populateRooms(rooms, bossIndex, p, rng)
+	l.prepare()
+	return l
+}
+
+// split делит узел, пока обе части вмещают комнату со стенами
+func (n *bspNode) split(rng *RandStream, minLeaf int) {
+	var horizontal bool
+	switch {
+	case n.w > n.h*5/4:
+		horizontal = false
+	case n.h > n.w*5/4:
+		horizontal = true
+	default:
+		horizontal = rng.Chance(0.5)
+	}
+
+	size := n.w
+	if horizontal {
+		size = n.h
+	}
+	if size < 2*minLeaf {
+		return
+	}
+
+	cut := minLeaf + rng.Intn(size-2*minLeaf+1)
+	if horizontal {
+		n.left = &bspNode{x: n.x, y: n.y, w: n.w, h: cut}
+		n.right = &bspNode{x: n.x, y: n.y + cut, w: n.w, h: n.h - cut}
+	} else {
+		n.left = &bspNode{x: n.x, y: n.y, w: cut, h: n.h}
+		n.right = &bspNode{x: n.x + cut, y: n.y, w: n.w - cut, h: n.h}
+	}
+	n.left.split(rng, minLeaf)
+	n.right.split(rng, minLeaf)
+}
+
+// placeRooms создает комнату в каждом листе, оставляя стену по краю
+func (n *bspNode) placeRooms(rng *RandStream, p DungeonParams, rooms *[]Room) {
+	if n.left != nil {
+		n.left.placeRooms(rng, p, rooms)
+		n.right.placeRooms(rng, p, rooms)
+		return
+	}
+
+	maxW := min(p.MaxRoomSize, n.w-2)
+	maxH := min(p.MaxRoomSize, n.h-2)
+	w := p.MinRoomSize + rng.Intn(maxW-p.MinRoomSize+1)
+	h := p.MinRoomSize + rng.Intn(maxH-p.MinRoomSize+1)
+	n.room = &Room{
+		X: n.x + 1 + rng.Intn(n.w-w-1),
+		Y: n.y + 1 + rng.Intn(n.h-h-1),
+		W: w,
+		H: h,
+	}
+	*rooms = append(*rooms, *n.room)
+}
+
+// anyRoom возвращает случайную комнату поддерева
+func (n *bspNode) anyRoom(rng *RandStream) Room {
+	if n.room != nil {
+		return *n.room
+	}
+	if rng.Chance(0.5) {
+		return n.left.anyRoom(rng)
+	}
+	return n.right.anyRoom(rng)
+}
+
+// connect соединяет коридорами поддеревья каждого узла
+func (n *bspNode) connect(tiles [][]TileType, rng *RandStream) {
+	if n.left == nil {
+		return
+	}
+	n.left.connect(tiles, rng)
+	n.right.connect(tiles, rng)
+	carveCorridor(tiles, n.left.anyRoom(rng).Center(), n.right.anyRoom(rng).Center(), rng.Chance(0.5))
+}
+
+// carveCorridor прорубает Г-образный коридор шириной в одну клетку
+func carveCorridor(tiles [][]TileType, a, b TilePoint, horizontalFirst bool) {
+	corner := TilePoint{X: b.X, Y: a.Y}
+	if !horizontalFirst {
+		corner = TilePoint{X: a.X, Y: b.Y}
+	}
+	carveLine(tiles, a, corner)
+	carveLine(tiles, corner, b)
+}
+
+func carveLine(tiles [][]TileType, a, b TilePoint) {
+	dx, dy := sign(b.X-a.X), sign(b.Y-a.Y)
+	for p := a; ; p = (TilePoint{X: p.X + dx, Y: p.Y + dy}) {
+		if tiles[p.Y][p.X] == TileWall {
+			tiles[p.Y][p.X] = TileFloor
+		}
+		if p == b {
+			return
+		}
+	}
+}
+
+func sign(v int) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
+
+// farthestRoom возвращает индекс комнаты, до которой дальше всего идти от from
+func farthestRoom(tiles [][]TileType, rooms []Room, from TilePoint) int {
+	dist := walkDistances(tiles, from)
+	best, bestDist := 0, -1
+	for i, r := range rooms {
+		c := r.Center()
+		if d := dist[c.Y][c.X]; d > bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// walkDistances считает длину пути по клеткам от from (-1 - недостижимо)
+func walkDistances(tiles [][]TileType, from TilePoint) [][]int {
+	dist := make([][]int, len(tiles))
+	for y := range dist {
+		dist[y] = make([]int, len(tiles[y]))
+		for x := range dist[y] {
+			dist[y][x] = -1
+		}
+	}
+
+	dist[from.Y][from.X] = 0
+	queue := []TilePoint{from}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, step := range orthogonalSteps {
+			x, y := cur.X+step.X, cur.Y+step.Y
+			if y < 0 || y >= len(tiles) || x < 0 || x >= len(tiles[y]) {
+				continue
+			}
+			if dist[y][x] >= 0 || !isWalkableTile(tiles[y][x]) {
+				continue
+			}
+			dist[y][x] = dist[cur.Y][cur.X] + 1
+			queue = append(queue, TilePoint{x, y})
+		}
+	}
+	return dist
+}
+
+// lockRoom ставит запертые двери там, где коридоры входят в комнату:
+// в проходы кольца стен, из которых можно шагнуть дальше от комнаты.
+// Коридор, идущий вдоль стены, получает дверь только там, где уходит от нее.
+func lockRoom(tiles [][]TileType, r Room, keyID int) []Door {
+	enclosed := func(x, y int) bool {
+		return x >= r.X-1 && x <= r.X+r.W && y >= r.Y-1 && y <= r.Y+r.H
+	}
+	var doors []Door
+	for y := r.Y - 1; y <= r.Y+r.H; y++ {
+		for x := r.X - 1; x <= r.X+r.W; x++ {
+			onRing := x == r.X-1 || x == r.X+r.W || y == r.Y-1 || y == r.Y+r.H
+			if !onRing || tiles[y][x] != TileFloor {
+				continue
+			}
+			for _, step := range orthogonalSteps {
+				nx, ny := x+step.X, y+step.Y
+				if enclosed(nx, ny) || ny < 0 || ny >= len(tiles) || nx < 0 || nx >= len(tiles[ny]) {
+					continue
+				}
+				if isWalkableTile(tiles[ny][nx]) {
+					doors = append(doors, Door{Tile: TilePoint{x, y}, KeyID: keyID})
+					break
+				}
+			}
+		}
+	}
+	return doors
+}
+
+// pickKeyRoom выбирает комнату для ключа, достижимую от старта без
+// прохода через запертые двери. Стартовая комната - запасной вариант.
+func pickKeyRoom(tiles [][]TileType, rooms []Room, doors []Door, startIndex, bossIndex int, rng *RandStream) int {
+	// Закрытые двери временно считаем стенами
+	for _, d := range doors {
+		tiles[d.Tile.Y][d.Tile.X] = TileWall
+	}
+	reachable := reachableFrom(tiles, rooms[startIndex].Center())
+	for _, d := range doors {
+		tiles[d.Tile.Y][d.Tile.X] = TileFloor
+	}
+
+	var candidates []int
+	for i, r := range rooms {
+		c := r.Center()
+		if i != startIndex && i != bossIndex && reachable[c.Y][c.X] {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return startIndex
+	}
+	return candidates[rng.Intn(len(candidates))]
+}
+
+// populateRooms расставляет обычных врагов по комнатам (кроме стартовой),
+// от нуля до p.EnemiesPerRoom в каждой, и босса в его комнате (-1 - без босса)
+func populateRooms(rooms []Room, bossIndex int, p DungeonParams, rng *RandStream) []Enemy {
+	kinds := SpawnableEnemyTypes()
+	bosses := BossEnemyTypes()
+
+	var enemies []Enemy
+	for i, r := range rooms {
+		if i == 0 {
+			continue
+		}
+		if i == bossIndex && len(bosses) > 0 {
+			kind := bosses[rng.Intn(len(bosses))]
+			enemies = append(enemies, NewEnemy(kind, tileTopLeft(r.Center())))
+			continue
+		}
+		for n := rng.Intn(p.EnemiesPerRoom + 1); n > 0; n-- {
+			kind := kinds[rng.Intn(len(kinds))]
+			enemies = append(enemies, NewEnemy(kind, tileTopLeft(r.randomTile(rng))))
+		}
+	}
+	return enemies
+}
+
+// Цвета дверей и ключей по KeyID
+var keyColors = []color.RGBA{
+	{200, 170, 40, 255},
+	{200, 60, 60, 255},
+	{60, 110, 210, 255},
+}
+
+func keyColor(id int) color.RGBA {
+	return keyColors[absInt(id)%len(keyColors)]
+}
@@ -0,0 +1,688 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Инструменты редактора
+type editorTool int
+
+const (
+	toolPaint  editorTool = iota // Рисование тайлов
+	toolEnemy                    // Расстановка врагов
+	toolStart                    // Стартовая точка игрока
+	toolSelect                   // Выделение, перемещение и свойства объектов
+)
+
+var editorToolNames = []string{"Paint", "Enemy", "Start", "Select"}
+
+// Тип выделенного объекта
+type selectionKind int
+
+const (
+	selNone selectionKind = iota
+	selEnemy
+	selStart
+	selExit
+	selKey
+)
+
+type editorSelection struct {
+	kind  selectionKind
+	index int
+}
+
+// Размер маркера стартовой точки (спрайт игрока слишком мал для клика)
+const startMarkerSize = 24
+
+// editorSnapshot - копия редактируемых данных уровня для отмены и повтора
+type editorSnapshot struct {
+	tiles   [][]TileType
+	layers  [][]int   // Data каждого слоя TiledMap
+	chunks  [][]Chunk // Куски каждого слоя бесконечной карты
+	enemies []Enemy
+	start   Position
+	exit    Position
+	doors   []Door
+	keys    []Key
+}
+
+func captureLevel(l *Level) editorSnapshot {
+	s := editorSnapshot{
+		enemies: append([]Enemy(nil), l.Enemies...),
+		start:   l.StartPosition,
+		exit:    l.ExitPosition,
+		doors:   append([]Door(nil), l.Doors...),
+		keys:    append([]Key(nil), l.Keys...),
+	}
+	for _, row := range l.Map {
+		s.tiles = append(s.tiles, append([]TileType(nil), row...))
+	}
+	if l.TiledMap != nil {
+		for _, layer := range l.TiledMap.Layers {
+			s.layers = append(s.layers, append([]int(nil), layer.Data...))
+			s.chunks = append(s.chunks, copyChunks(layer.Chunks))
+		}
+	}
+	return s
+}
+
+func (s editorSnapshot) restore(l *Level) {
+	l.Enemies = append([]Enemy(nil), s.enemies...)
+	l.StartPosition = s.start
+	l.ExitPosition = s.exit
+	l.Doors = append([]Door(nil), s.doors...)
+	l.Keys = append([]Key(nil), s.keys...)
+	l.Map = l.Map[:0]
+	for _, row := range s.tiles {
+		l.Map = append(l.Map, append([]TileType(nil), row...))
+	}
+	if l.TiledMap != nil {
+		for i := range l.TiledMap.Layers {
+			l.TiledMap.Layers[i].Data = append([]int(nil), s.layers[i]...)
+			l.TiledMap.Layers[i].Chunks = copyChunks(s.chunks[i])
+			l.TiledMap.Layers[i].indexChunks()
+		}
+		l.InvalidateTiles()
+	}
+}
+
+// EditorScene - редактор текущего уровня поверх игры (F2 в режиме отладки).
+// Редактируется авторский вид уровня, а не состояние игры: при входе
+// враги и ключи возвращаются на места. После выхода игра продолжается
+// на измененном уровне, Ctrl+S сохраняет его в Tiled JSON.
+type EditorScene struct {
+	tool      editorTool
+	brush     int // Индекс в brushes
+	layer     int // Индекс в tileLayers
+	enemyType int // Индекс в EnemyTypeNames
+	focus     Position
+
+	selection  editorSelection
+	property   int // Выбранная строка панели свойств
+	dragging   bool
+	dragOffset Position
+	stroking   bool // Мазок или перетаскивание отменяются одним шагом
+
+	undo, redo []editorSnapshot
+
+	status   string
+	statusAt time.Time
+}
+
+func NewEditorScene() *EditorScene {
+	return &EditorScene{tool: toolPaint}
+}
+
+func (e *EditorScene) Enter(g *Game) {
+	gameClock.Pause()
+	g.levels[g.currentLevel].resetToAuthored()
+	g.playerContacts = g.playerContacts[:0]
+	e.focus = g.player.Center()
+	e.selection = editorSelection{}
+}
+
+// Exit запоминает правки как авторский вид уровня и перестраивает
+// навигацию и индекс врагов под измененный уровень
+func (e *EditorScene) Exit(g *Game) {
+	level := &g.levels[g.currentLevel]
+	level.keepAsAuthored()
+	level.prepare()
+	g.playerContacts = g.playerContacts[:0]
+	g.settleTriggers()
+	gameClock.Resume()
+}
+
+func (e *EditorScene) Update(g *Game) error {
+	if inpututil.IsKeyJustPressed(ebiten.KeyF2) || inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.scenes.Pop()
+		return nil
+	}
+
+	level := &g.levels[g.currentLevel]
+	if ebiten.IsKeyPressed(ebiten.KeyControl) || ebiten.IsKeyPressed(ebiten.KeyMeta) {
+		e.handleShortcuts(level)
+		return nil
+	}
+
+	e.moveCamera(g, level)
+	e.handleToolKeys(level)
+	e.handleMouse(g, level)
+	if e.tool == toolSelect {
+		e.handleProperties(level)
+	}
+	return nil
+}
+
+func (e *EditorScene) handleShortcuts(level *Level) {
+	shift := ebiten.IsKeyPressed(ebiten.KeyShift)
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyZ) && shift, inpututil.IsKeyJustPressed(ebiten.KeyY):
+		e.Redo(level)
+	case inpututil.IsKeyJustPressed(ebiten.KeyZ):
+		e.Undo(level)
+	case inpututil.IsKeyJustPressed(ebiten.KeyS):
+		e.Save(level)
+	}
+}
+
+func (e *EditorScene) moveCamera(g *Game, level *Level) {
+	speed := EditorCameraSpeed
+	if ebiten.IsKeyPressed(ebiten.KeyShift) {
+		speed *= 3
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyW) {
+		e.focus.Y -= speed
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyS) {
+		e.focus.Y += speed
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyA) {
+		e.focus.X -= speed
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyD) {
+		e.focus.X += speed
+	}
+
+	worldW, worldH := level.PixelSize()
+	e.focus.X = clampFloat(e.focus.X, 0, worldW)
+	e.focus.Y = clampFloat(e.focus.Y, 0, worldH)
+	g.camera.Follow(e.focus, worldW, worldH, g.display.Width(), g.display.Height())
+}
+
+func (e *EditorScene) handleToolKeys(level *Level) {
+	for i, key := range []ebiten.Key{ebiten.Key1, ebiten.Key2, ebiten.Key3, ebiten.Key4} {
+		if inpututil.IsKeyJustPressed(key) {
+			e.tool = editorTool(i)
+			e.selection = editorSelection{}
+		}
+	}
+
+	step := 0
+	if inpututil.IsKeyJustPressed(ebiten.KeyQ) {
+		step = -1
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyE) {
+		step = 1
+	}
+	if step != 0 {
+		switch e.tool {
+		case toolPaint:
+			e.brush = wrapIndex(e.brush+step, len(e.brushes(level)))
+		case toolEnemy:
+			e.enemyType = wrapIndex(e.enemyType+step, len(EnemyTypeNames()))
+		}
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		e.layer = wrapIndex(e.layer+1, len(tileLayers(level)))
+	}
+
+	if e.tool == toolSelect && (inpututil.IsKeyJustPressed(ebiten.KeyDelete) || inpututil.IsKeyJustPressed(ebiten.KeyBackspace)) {
+		e.deleteSelection(level)
+	}
+}
+
+func wrapIndex(i, n int) int {
+	if n == 0 {
+		return 0
+	}
+	return (i%n + n) % n
+}
+
+// cursor возвращает позицию курсора в мире и клетку под ним
+func (e *EditorScene) cursor(g *Game, level *Level) (Position, TilePoint) {
+	mx, my := g.display.CursorPosition()
+	world := g.camera.ToWorld(Position{X: mx, Y: my})
+	tw, th := level.TileSize()
+	return world, TilePoint{X: floorDiv(int(world.X), tw), Y: floorDiv(int(world.Y), th)}
+}
+
+func (e *EditorScene) handleMouse(g *Game, level *Level) {
+	left := ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft)
+	right := ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight)
+	if !left && !right {
+		e.stroking = false
+		e.dragging = false
+		return
+	}
+
+	// Клики по панели не попадают в мир
+	if _, my := g.display.CursorPosition(); my < editorPanelHeight {
+		return
+	}
+
+	world, tile := e.cursor(g, level)
+	leftClick := inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft)
+	rightClick := inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight)
+
+	switch e.tool {
+	case toolPaint:
+		brushes := e.brushes(level)
+		if left && len(brushes) > 0 {
+			e.paint(level, tile, brushes[e.brush%len(brushes)])
+		} else if right {
+			e.paint(level, tile, e.emptyTile(level))
+		}
+
+	case toolEnemy:
+		if leftClick {
+			e.record(level)
+			kind := EnemyTypeNames()[e.enemyType]
+			pos := Position{X: world.X - EnemySpriteWidth/2, Y: world.Y - EnemySpriteHeight/2}
+			level.Enemies = append(level.Enemies, NewEnemy(kind, pos))
+		} else if rightClick {
+			if sel := e.hitTest(level, world); sel.kind == selEnemy {
+				e.record(level)
+				level.Enemies = append(level.Enemies[:sel.index], level.Enemies[sel.index+1:]...)
+			}
+		}
+
+	case toolStart:
+		if leftClick {
+			e.record(level)
+			level.StartPosition = Position{X: world.X - startMarkerSize/2, Y: world.Y - startMarkerSize/2}
+		}
+
+	case toolSelect:
+		if leftClick {
+			e.selection = e.hitTest(level, world)
+			e.property = 0
+			if pos, ok := e.selectedPosition(level); ok {
+				e.dragging = true
+				e.dragOffset = Position{X: pos.X - world.X, Y: pos.Y - world.Y}
+			}
+		}
+		if left && e.dragging {
+			pos := Position{X: world.X + e.dragOffset.X, Y: world.Y + e.dragOffset.Y}
+			if cur, _ := e.selectedPosition(level); cur != pos {
+				e.beginStroke(level)
+				e.setSelectedPosition(level, pos)
+			}
+		}
+	}
+}
+
+// record сохраняет состояние уровня перед правкой
+func (e *EditorScene) record(level *Level) {
+	e.undo = append(e.undo, captureLevel(level))
+	if len(e.undo) > EditorUndoLimit {
+		e.undo = e.undo[1:]
+	}
+	e.redo = nil
+}
+
+// beginStroke сохраняет состояние один раз за мазок или перетаскивание
+func (e *EditorScene) beginStroke(level *Level) {
+	if !e.stroking {
+		e.record(level)
+		e.stroking = true
+	}
+}
+
+func (e *EditorScene) Undo(level *Level) {
+	if len(e.undo) == 0 {
+		return
+	}
+	e.redo = append(e.redo, captureLevel(level))
+	e.undo[len(e.undo)-1].restore(level)
+	e.undo = e.undo[:len(e.undo)-1]
+	e.selection = editorSelection{}
+}
+
+func (e *EditorScene) Redo(level *Level) {
+	if len(e.redo) == 0 {
+		return
+	}
+	e.undo = append(e.undo, captureLevel(level))
+	e.redo[len(e.redo)-1].restore(level)
+	e.redo = e.redo[:len(e.redo)-1]
+	e.selection = editorSelection{}
+}
+
+// brushes возвращает доступные кисти: типы тайлов для Level.Map
+// или GID тайлсетов для карт Tiled
+func (e *EditorScene) brushes(level *Level) []int {
+	if level.TiledMap == nil {
+		brushes := make([]int, len(terrainTiles))
+		for i, t := range terrainTiles {
+			brushes[i] = int(t)
+		}
+		return brushes
+	}
+
+	gids := make([]int, 0, len(level.TileImages))
+	for gid := range level.TileImages {
+		gids = append(gids, gid)
+	}
+	sort.Ints(gids)
+	return gids
+}
+
+// emptyTile - чем стирает правая кнопка
+func (e *EditorScene) emptyTile(level *Level) int {
+	if level.TiledMap == nil {
+		return int(TileGrass)
+	}
+	return 0
+}
+
+// tileLayers возвращает индексы тайловых слоев карты Tiled
+func tileLayers(level *Level) []int {
+	if level.TiledMap == nil {
+		return nil
+	}
+	var layers []int
+	for i, layer := range level.TiledMap.Layers {
+		if layer.Type == "tilelayer" {
+			layers = append(layers, i)
+		}
+	}
+	return layers
+}
+
+// paint ставит тайл value в клетку p текущего слоя
+func (e *EditorScene) paint(level *Level, p TilePoint, value int) {
+	if level.TiledMap == nil {
+		if p.Y < 0 || p.Y >= len(level.Map) || p.X < 0 || p.X >= len(level.Map[p.Y]) {
+			return
+		}
+		if level.Map[p.Y][p.X] == TileType(value) {
+			return
+		}
+		e.beginStroke(level)
+		level.Map[p.Y][p.X] = TileType(value)
+		return
+	}
+
+	layers := tileLayers(level)
+	if len(layers) == 0 {
+		return
+	}
+	layer := &level.TiledMap.Layers[layers[e.layer%len(layers)]]
+	if p.X < 0 || p.Y < 0 || p.X >= layer.Width || p.Y >= layer.Height {
+		return
+	}
+	if layer.TileAt(p.X, p.Y) == value {
+		return
+	}
+	e.beginStroke(level)
+	layer.SetTileAt(p.X, p.Y, value)
+	level.InvalidateTile(p.X, p.Y)
+}
+
+// hitTest ищет объект под точкой, сверху вниз по порядку отрисовки
+func (e *EditorScene) hitTest(level *Level, p Position) editorSelection {
+	pt := image.Pt(int(p.X), int(p.Y))
+	for i := len(level.Enemies) - 1; i >= 0; i-- {
+		if pt.In(level.Enemies[i].GetCollisionRect().Inset(-EnemyHitboxReduction)) {
+			return editorSelection{selEnemy, i}
+		}
+	}
+	if pt.In(markerRect(level.StartPosition, startMarkerSize, startMarkerSize)) {
+		return editorSelection{selStart, 0}
+	}
+	tw, th := level.TileSize()
+	for i, key := range level.Keys {
+		if !key.Taken && pt.In(markerRect(key.Position, tw, th)) {
+			return editorSelection{selKey, i}
+		}
+	}
+	// Выход из объекта level_exit - зона произвольной формы, ее правят в Tiled
+	if level.ExitPosition != (Position{}) && !level.hasTrigger("level_exit") && pt.In(markerRect(level.ExitPosition, tw, th)) {
+		return editorSelection{selExit, 0}
+	}
+	return editorSelection{}
+}
+
+func markerRect(pos Position, w, h int) image.Rectangle {
+	x, y := int(pos.X), int(pos.Y)
+	return image.Rect(x, y, x+w, y+h)
+}
+
+func (e *EditorScene) selectedPosition(level *Level) (Position, bool) {
+	switch e.selection.kind {
+	case selEnemy:
+		return level.Enemies[e.selection.index].Position, true
+	case selStart:
+		return level.StartPosition, true
+	case selExit:
+		return level.ExitPosition, true
+	case selKey:
+		return level.Keys[e.selection.index].Position, true
+	}
+	return Position{}, false
+}
+
+func (e *EditorScene) setSelectedPosition(level *Level, pos Position) {
+	switch e.selection.kind {
+	case selEnemy:
+		level.Enemies[e.selection.index].Position = pos
+	case selStart:
+		level.StartPosition = pos
+	case selExit:
+		level.ExitPosition = pos
+	case selKey:
+		level.Keys[e.selection.index].Position = pos
+	}
+}
+
+// deleteSelection удаляет выделенный объект. Стартовую точку удалить нельзя.
+func (e *EditorScene) deleteSelection(level *Level) {
+	switch e.selection.kind {
+	case selEnemy:
+		e.record(level)
+		level.Enemies = append(level.Enemies[:e.selection.index], level.Enemies[e.selection.index+1:]...)
+	case selKey:
+		e.record(level)
+		level.Keys = append(level.Keys[:e.selection.index], level.Keys[e.selection.index+1:]...)
+	case selExit:
+		e.record(level)
+		level.ExitPosition = Position{}
+	default:
+		return
+	}
+	e.selection = editorSelection{}
+}
+
+// properties возвращает строки панели свойств выделенного объекта
+func (e *EditorScene) properties(level *Level) []string {
+	switch e.selection.kind {
+	case selEnemy:
+		enemy := level.Enemies[e.selection.index]
+		return []string{
+			"Type: " + enemy.Type,
+			fmt.Sprintf("Health: %d", enemy.Health),
+			fmt.Sprintf("Speed: %.1f", enemy.Speed),
+			fmt.Sprintf("Damage: %d", enemy.Damage),
+		}
+	case selKey:
+		return []string{fmt.Sprintf("Key ID: %d", level.Keys[e.selection.index].ID)}
+	}
+	return nil
+}
+
+// handleProperties: вверх/вниз - выбор свойства, влево/вправо - изменение
+func (e *EditorScene) handleProperties(level *Level) {
+	props := e.properties(level)
+	if len(props) == 0 {
+		return
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyDown) {
+		e.property = wrapIndex(e.property+1, len(props))
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyUp) {
+		e.property = wrapIndex(e.property-1, len(props))
+	}
+
+	delta := 0
+	if inpututil.IsKeyJustPressed(ebiten.KeyRight) {
+		delta = 1
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyLeft) {
+		delta = -1
+	}
+	if delta == 0 {
+		return
+	}
+
+	e.record(level)
+	switch e.selection.kind {
+	case selEnemy:
+		enemy := &level.Enemies[e.selection.index]
+		switch e.property {
+		case 0:
+			// Смена типа возвращает характеристики из реестра, маршрут и объект Tiled остаются
+			names := EnemyTypeNames()
+			next := wrapIndex(sort.SearchStrings(names, enemy.Type)+delta, len(names))
+			patrol, id := enemy.Patrol, enemy.ObjectID
+			*enemy = NewEnemy(names[next], enemy.Position)
+			enemy.Patrol, enemy.ObjectID = patrol, id
+		case 1:
+			enemy.Health = max(1, enemy.Health+delta*5)
+		case 2:
+			enemy.Speed = max(0, enemy.Speed+float64(delta)*0.1)
+		case 3:
+			enemy.Damage = max(0, enemy.Damage+delta)
+		}
+	case selKey:
+		key := &level.Keys[e.selection.index]
+		key.ID = max(0, key.ID+delta)
+	}
+}
+
+// Save записывает уровень в Tiled JSON: карты Tiled - в исходный файл,
+// сгенерированные уровни - в data/maps вместе с тайлсетом местности
+func (e *EditorScene) Save(level *Level) {
+	path := level.Source
+	if path == "" {
+		name := strings.ToLower(strings.ReplaceAll(level.Name, " ", "_"))
+		path = filepath.Join("data", "maps", name+".json")
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err == nil {
+		if level.TiledMap != nil {
+			err = writeTiledMap(tiledMapForSave(level), path)
+		} else {
+			err = saveTiledMap(tiledMapFromLevel(level), path)
+		}
+	}
+
+	if err != nil {
+		log.Printf("Failed to save level: %v", err)
+		e.setStatus("Save failed: " + err.Error())
+		return
+	}
+	log.Printf("Level saved to %s", path)
+	e.setStatus("Saved to " + path)
+}
+
+func (e *EditorScene) setStatus(s string) {
+	e.status = s
+	e.statusAt = time.Now()
+}
+
+// Высота панели редактора вверху экрана
+const editorPanelHeight = 90
+
+func (e *EditorScene) Draw(screen *ebiten.Image, g *Game) {
+	level := &g.levels[g.currentLevel]
+
+	screen.Fill(color.RGBA{0x30, 0x30, 0x30, 0xFF})
+	g.drawWorld(screen)
+
+	e.drawMarkers(screen, g, level)
+	e.drawPanel(screen, g, level)
+}
+
+func (e *EditorScene) drawMarkers(screen *ebiten.Image, g *Game, level *Level) {
+	start := g.camera.ToScreen(level.StartPosition)
+	vector.DrawFilledRect(screen, float32(start.X), float32(start.Y), startMarkerSize, startMarkerSize, color.RGBA{40, 90, 220, 200}, false)
+	text.Draw(screen, "S", g.screenManager.fontFace, int(start.X)+8, int(start.Y)+16, color.White)
+
+	// Клетка под курсором
+	_, tile := e.cursor(g, level)
+	tw, th := level.TileSize()
+	cell := g.camera.ToScreen(Position{X: float64(tile.X * tw), Y: float64(tile.Y * th)})
+	vector.StrokeRect(screen, float32(cell.X), float32(cell.Y), float32(tw), float32(th), 1, color.White, false)
+
+	// Выделенный объект
+	var r image.Rectangle
+	switch e.selection.kind {
+	case selEnemy:
+		r = level.Enemies[e.selection.index].GetCollisionRect().Inset(-EnemyHitboxReduction)
+	case selStart:
+		r = markerRect(level.StartPosition, startMarkerSize, startMarkerSize)
+	case selExit:
+		r = markerRect(level.ExitPosition, tw, th)
+	case selKey:
+		r = markerRect(level.Keys[e.selection.index].Position, tw, th)
+	default:
+		return
+	}
+	pos := g.camera.ToScreen(Position{X: float64(r.Min.X), Y: float64(r.Min.Y)})
+	vector.StrokeRect(screen, float32(pos.X)-2, float32(pos.Y)-2, float32(r.Dx())+4, float32(r.Dy())+4, 2, color.RGBA{255, 255, 0, 255}, false)
+}
+
+func (e *EditorScene) drawPanel(screen *ebiten.Image, g *Game, level *Level) {
+	vector.DrawFilledRect(screen, 0, 0, float32(g.display.Width()), editorPanelHeight, color.RGBA{0, 0, 0, 180}, false)
+
+	_, tile := e.cursor(g, level)
+	toolLine := fmt.Sprintf("Tool: %s   Tile: %d,%d   Undo: %d   Redo: %d",
+		editorToolNames[e.tool], tile.X, tile.Y, len(e.undo), len(e.redo))
+	switch e.tool {
+	case toolPaint:
+		toolLine += "   Brush: " + e.brushName(level)
+		if layers := tileLayers(level); len(layers) > 0 {
+			toolLine += "   Layer: " + level.TiledMap.Layers[layers[e.layer%len(layers)]].Name
+		}
+	case toolEnemy:
+		toolLine += "   Enemy: " + EnemyTypeNames()[e.enemyType]
+	}
+
+	lines := []string{
+		"EDITOR  [F2/Esc] back  [WASD] camera  [1-4] tool  [Q/E] brush  [Tab] layer  [Ctrl+Z/Y] undo/redo  [Ctrl+S] save",
+		toolLine,
+	}
+	if e.status != "" && time.Since(e.statusAt) < 3*time.Second {
+		lines = append(lines, e.status)
+	}
+	for i, line := range lines {
+		text.Draw(screen, line, g.screenManager.fontFace, 10, 20+i*18, color.White)
+	}
+
+	// Панель свойств выделенного объекта
+	for i, prop := range e.properties(level) {
+		prefix := "  "
+		if i == e.property {
+			prefix = "> "
+		}
+		text.Draw(screen, prefix+prop, g.screenManager.fontFace, 10, editorPanelHeight+20+i*16, color.RGBA{255, 255, 0, 255})
+	}
+}
+
+func (e *EditorScene) brushName(level *Level) string {
+	brushes := e.brushes(level)
+	if len(brushes) == 0 {
+		return "-"
+	}
+	b := brushes[e.brush%len(brushes)]
+	if level.TiledMap == nil {
+		return TileType(b).String()
+	}
+	return fmt.Sprintf("GID %d", b)
+}
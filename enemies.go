@@ -0,0 +1,249 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"sort"
+	"time"
+)
+
+// EnemyStats - характеристики типа врага
+type EnemyStats struct {
+	Health     int
+	Speed      float64
+	Damage     int
+	DamageType DamageType
+	Color      color.RGBA // Цвет заглушки, пока нет спрайта
+	Boss       bool       // Ставится только в комнату босса
+	Flying     bool       // Местность (вода, песок, лава) не действует
+	Nocturnal  bool       // Ночью быстрее и замечает издалека; дневные ночью видят хуже
+}
+
+// enemyTypes - реестр типов врагов по Enemy.Type
+var enemyTypes = map[string]EnemyStats{
+	"goblin":   {Health: 30, Speed: 1.5, Damage: 10, Color: color.RGBA{255, 0, 0, 255}},
+	"bat":      {Health: 15, Speed: 2.2, Damage: 5, Color: color.RGBA{150, 0, 0, 255}, Flying: true, Nocturnal: true},
+	"skeleton": {Health: 50, Speed: 1.0, Damage: 15, Color: color.RGBA{200, 200, 200, 255}, Nocturnal: true},
+	"ogre":     {Health: 200, Speed: 0.8, Damage: 25, Color: color.RGBA{90, 120, 40, 255}, Boss: true},
+}
+
+// defaultEnemyType используется для неизвестных типов
+const defaultEnemyType = "goblin"
+
+// NewEnemy создает врага с характеристиками из реестра
+func NewEnemy(kind string, pos Position) Enemy {
+	stats, ok := enemyTypes[kind]
+	if !ok {
+		stats = enemyTypes[defaultEnemyType]
+	}
+	return Enemy{
+		Type:       kind,
+		Health:     stats.Health,
+		Position:   pos,
+		Speed:      stats.Speed,
+		Damage:     stats.Damage,
+		DamageType: stats.DamageType,
+	}
+}
+
+// IsKnownEnemyType проверяет, есть ли тип в реестре
+func IsKnownEnemyType(kind string) bool {
+	_, ok := enemyTypes[kind]
+	return ok
+}
+
+// EnemyTypeNames возвращает отсортированный список типов врагов
+func EnemyTypeNames() []string {
+	names := make([]string, 0, len(enemyTypes))
+	for name := range enemyTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SpawnableEnemyTypes возвращает обычные (не боссы) типы врагов для генераторов
+func SpawnableEnemyTypes() []string {
+	var names []string
+	for _, name := range EnemyTypeNames() {
+		if !enemyTypes[name].Boss {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// BossEnemyTypes возвращает типы боссов
+func BossEnemyTypes() []string {
+	var names []string
+	for _, name := range EnemyTypeNames() {
+		if enemyTypes[name].Boss {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// applyProperties переопределяет характеристики из реестра свойствами
+// объекта Tiled: health, speed, damage
+func (e *Enemy) applyProperties(props []Property) {
+	if v, ok := propertyFloat(props, "health"); ok {
+		e.Health = int(v)
+	}
+	if v, ok := propertyFloat(props, "speed"); ok {
+		e.Speed = v
+	}
+	if v, ok := propertyFloat(props, "damage"); ok {
+		e.Damage = int(v)
+	}
+}
+
+// properties возвращает свойства объекта Tiled, отличающиеся от реестра
+func (e *Enemy) properties() []Property {
+	base := NewEnemy(e.Type, e.Position)
+	var props []Property
+	if e.Health != base.Health {
+		props = append(props, Property{Name: "health", Type: "int", Value: e.Health})
+	}
+	if e.Speed != base.Speed {
+		props = append(props, Property{Name: "speed", Type: "float", Value: e.Speed})
+	}
+	if e.Damage != base.Damage {
+		props = append(props, Property{Name: "damage", Type: "int", Value: e.Damage})
+	}
+	if e.Patrol.ObjectID != 0 {
+		props = append(props, Property{Name: "patrol", Type: "object", Value: e.Patrol.ObjectID})
+	}
+	return props
+}
+
+// Center возвращает центр хитбокса врага
+func (e *Enemy) Center() Position {
+	r := e.GetCollisionRect()
+	return Position{
+		X: float64(r.Min.X+r.Max.X) / 2,
+		Y: float64(r.Min.Y+r.Max.Y) / 2,
+	}
+}
+
+// Update преследует цель по пути, найденному через навигацию уровня.
+// night меняет дальность обнаружения и скорость (см. EnemyStats.Nocturnal).
+func (e *Enemy) Update(target Position, level *Level, night bool, rng *RandStream) {
+	stats := enemyTypes[e.Type]
+	aggro, speed := EnemyAggroRange, 1.0
+	switch {
+	case night && stats.Nocturnal:
+		aggro, speed = EnemyAggroRange*NightAggroScale, NightSpeedScale
+	case night && !level.Lit(target):
+		// Дневные враги в темноте замечают цель, только если она у огня
+		aggro = EnemyAggroRange * NightSightScale
+	}
+	e.steer(target, level.Nav, aggro, rng)
+
+	e.terrain = defaultTerrain
+	if !stats.Flying {
+		e.terrain = level.TerrainAt(e.Center())
+	}
+	t := e.terrain
+	t.Speed *= speed
+	dx, dy := e.body.Step(t)
+	e.Position.X += dx
+	e.Position.Y += dy
+
+	// Урон от местности врагам идет с тем же интервалом, что и игроку
+	if e.terrain.Damage > 0 && gameClock.Since(e.lastTerrainHit) >= HazardDamageInterval {
+		e.lastTerrainHit = gameClock.Now()
+		e.Health -= e.terrain.Damage
+	}
+}
+
+// removeDeadEnemies убирает врагов, погибших от местности, и возвращает их.
+// Возвращает true, если список изменился.
+func (l *Level) removeDeadEnemies() []Enemy {
+	var dead []Enemy
+	alive := l.Enemies[:0]
+	for _, e := range l.Enemies {
+		if e.Health > 0 {
+			alive = append(alive, e)
+		} else {
+			dead = append(dead, e)
+		}
+	}
+	clear(l.Enemies[len(alive):])
+	l.Enemies = alive
+	return dead
+}
+
+// steer задает направление ускорения к следующей точке пути.
+// Дальше aggro цель не видна, и враг ходит по маршруту.
+func (e *Enemy) steer(target Position, nav *Pathfinder, aggro float64, rng *RandStream) {
+	if nav == nil || e.Speed <= 0 {
+		return
+	}
+
+	center := e.Center()
+	if distance(center, target) > aggro {
+		e.path = nil
+		e.patrol(center)
+		return
+	}
+
+	// Периодически пересчитываем путь, так как цель движется. Интервал
+	// берется из потока AI, чтобы пересчеты врагов не совпадали по тикам.
+	if gameClock.Since(e.lastRepath) > e.repathDelay {
+		e.path = nav.FindPath(center, target)
+		e.lastRepath = gameClock.Now()
+		e.repathDelay = time.Duration(float64(EnemyRepathInterval) * rng.Range(1-EnemyRepathJitter, 1+EnemyRepathJitter))
+	}
+
+	if len(e.path) == 0 {
+		return
+	}
+
+	next := e.path[0]
+	dist := distance(center, next)
+	if dist <= math.Max(WaypointReachRadius, e.body.Speed()) {
+		e.path = e.path[1:]
+	}
+	if dist > 0 {
+		e.body.Accelerate((next.X-center.X)/dist, (next.Y-center.Y)/dist)
+	}
+}
+
+// patrol ведет врага по маршруту, пока игрок далеко
+func (e *Enemy) patrol(center Position) {
+	points := e.Patrol.Points
+	if len(points) == 0 {
+		return
+	}
+	if e.patrolStep == 0 {
+		e.patrolStep = 1
+	}
+	e.patrolIndex = clampInt(e.patrolIndex, 0, len(points)-1)
+
+	next := points[e.patrolIndex]
+	dist := distance(center, next)
+	if dist <= math.Max(WaypointReachRadius, e.body.Speed()) && len(points) > 1 {
+		switch {
+		case e.Patrol.Loop:
+			e.patrolIndex = (e.patrolIndex + 1) % len(points)
+		case e.patrolIndex+e.patrolStep < 0 || e.patrolIndex+e.patrolStep >= len(points):
+			e.patrolStep = -e.patrolStep
+			e.patrolIndex += e.patrolStep
+		default:
+			e.patrolIndex += e.patrolStep
+		}
+	}
+	if dist > 0 {
+		e.body.Accelerate((next.X-center.X)/dist, (next.Y-center.Y)/dist)
+	}
+}
+
+// ApplyKnockback отбрасывает врага с начальной скоростью v
+func (e *Enemy) ApplyKnockback(v Position) {
+	e.body.Impulse(v)
+}
+
+func distance(a, b Position) float64 {
+	return math.Hypot(b.X-a.X, b.Y-a.Y)
+}
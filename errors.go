@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// AssetKind - вид ресурса, который не удалось загрузить
+type AssetKind string
+
+const (
+	AssetMap     AssetKind = "map"
+	AssetTileset AssetKind = "tileset"
+	AssetImage   AssetKind = "image"
+	AssetSprite  AssetKind = "sprite"
+	AssetSound   AssetKind = "sound"
+)
+
+// Причины ошибок загрузки, которые можно проверить через errors.Is
+var (
+	ErrInvalidMap     = errors.New("invalid map")
+	ErrInvalidTileset = errors.New("invalid tileset")
+)
+
+// AssetError - ошибка загрузки одного ресурса. Required означает, что без
+// ресурса игра работает не так, как задумано (в строгом режиме -strict
+// такие ошибки не дают запуститься).
+type AssetError struct {
+	Kind     AssetKind
+	Path     string
+	Required bool
+	Err      error
+}
+
+func (e *AssetError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Kind, e.Path, e.Err)
+}
+
+func (e *AssetError) Unwrap() error {
+	return e.Err
+}
+
+func assetError(kind AssetKind, path string, required bool, err error) *AssetError {
+	return &AssetError{Kind: kind, Path: path, Required: required, Err: err}
+}
+
+// IsRequired сообщает, что ошибка касается обязательного ресурса
+func IsRequired(err error) bool {
+	var ae *AssetError
+	return errors.As(err, &ae) && ae.Required
+}
+
+// requiredErrors отбирает ошибки обязательных ресурсов
+func requiredErrors(errs []error) []error {
+	var required []error
+	for _, err := range errs {
+		if IsRequired(err) {
+			required = append(required, err)
+		}
+	}
+	return required
+}
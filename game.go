@@ -1,7 +1,10 @@
 package main
 
 import (
-	"time"
+	"errors"
+	"image"
+	"image/color"
+	"log"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
@@ -13,92 +16,298 @@ type InputHandler struct {
 
 type Game struct {
 	player        *Player
-	gameState     GameState
-	lastUpdate    time.Time
+	scenes        *SceneStack
 	input         InputHandler
 	screenManager *ScreenManager
+	display       *Display
+	camera        Camera
+	pauseMenu     *PauseMenu
 	levels        []Level
 	currentLevel  int
+
+	playerContacts []int // Индексы врагов, касающихся игрока в этом кадре
+	damage         *DamageSystem
+	sounds         *SoundPlayer
+	config         *Config
+	rng            *RNG
+	hotReload      *HotReloader // Только в режиме разработки (-dev)
+	startupErrors  []error      // Ошибки загрузки уровней и звуков при запуске
+	renderQueue    RenderQueue  // Сортировка спрайтов по глубине, переиспользуется между кадрами
+	dayCycle       DayCycle
+	lightMap       *ebiten.Image // Карта освещения, переиспользуется между кадрами
+	tileRender     TileRenderMode
 }
 
-func NewGame() *Game {
-	return &Game{
+// NewGame создает игру. Незагруженные ресурсы заменяются запасными,
+// а ошибки собираются в startupErrors.
+func NewGame(cfg *Config) *Game {
+	g := &Game{
 		player:        NewPlayer(),
-		gameState:     StatePlaying,
 		screenManager: NewScreenManager(),
-		levels:        CreateLevels(),
+		display:       NewDisplay(),
+		pauseMenu:     NewPauseMenu(),
+		damage:        NewDamageSystem(),
+		config:        cfg,
+		rng:           NewRNG(cfg.Seed),
+		dayCycle:      NewDayCycle(),
+	}
+
+	g.levels, g.startupErrors = g.loadLevels()
+	g.currentLevel = g.startLevel()
+	g.spawnPlayer()
+
+	g.screenManager.debug = cfg.Debug
+	g.player.godMode = cfg.GodMode
+
+	// Без окна звук и пауза по фокусу не нужны
+	if cfg.Headless() {
+		g.pauseMenu.autoPause = false
+	} else {
+		var soundErrors []error
+		g.sounds, soundErrors = NewSoundPlayer()
+		g.startupErrors = append(g.startupErrors, soundErrors...)
+		if cfg.Dev {
+			g.hotReload = NewHotReloader()
+			g.hotReload.WatchGame(g)
+		}
+	}
+
+	// Подписчики событий урона
+	g.damage.Subscribe(g.screenManager.onDamage)
+	g.damage.Subscribe(g.playDamageSound)
+
+	g.scenes = NewSceneStack(g)
+	g.scenes.Push(&PlayingScene{})
+
+	return g
+}
+
+// loadLevels загружает карту из -map или стандартный набор уровней.
+// Если карту из -map загрузить не удалось, играем в стандартные уровни
+// (ошибка обязательная: без окна и с -strict игра не запустится).
+func (g *Game) loadLevels() ([]Level, []error) {
+	if g.config.MapFile == "" {
+		return CreateLevels(g.rng.Level)
+	}
+	level, err := loadTiledLevel(g.config.MapFile)
+	if err != nil {
+		var ae *AssetError
+		if errors.As(err, &ae) {
+			ae.Required = true
+		}
+		log.Printf("Failed to load map, using the built-in levels: %v", err)
+		levels, problems := CreateLevels(g.rng.Level)
+		return levels, append([]error{err}, problems...)
+	}
+	return []Level{*level}, level.Warnings
+}
+
+// startLevel возвращает индекс стартового уровня из настроек
+func (g *Game) startLevel() int {
+	return clampInt(g.config.StartLevel, 0, len(g.levels)-1)
+}
+
+func (g *Game) playDamageSound(e DamageEvent) {
+	if e.Killed {
+		g.sounds.Play("death")
+		return
 	}
+	g.sounds.Play("hit_" + e.Source.Type.String())
 }
 
 func (g *Game) Update() error {
-	now := time.Now()
-	delta := now.Sub(g.lastUpdate)
-	g.lastUpdate = now
+	if inpututil.IsKeyJustPressed(ebiten.KeyF11) {
+		g.display.ToggleFullscreen()
+	}
 
-	switch g.gameState {
-	case StatePlaying:
-		g.updatePlaying(delta)
-	case StateMainMenu:
-		g.updateMainMenu()
-	case StateGameOver:
-		g.updateGameOver()
+	if g.hotReload != nil {
+		g.hotReload.Update(g)
 	}
 
-	return nil
+	return g.scenes.Update()
+}
+
+// Pause останавливает игровое время и открывает меню паузы
+func (g *Game) Pause() {
+	if _, ok := g.scenes.Top().(*PlayingScene); !ok {
+		return
+	}
+	g.scenes.Push(g.pauseMenu)
 }
 
-func (g *Game) updatePlaying(delta time.Duration) {
+// Resume закрывает меню паузы и продолжает игровое время
+func (g *Game) Resume() {
+	if g.scenes.Top() != g.pauseMenu {
+		return
+	}
+	g.scenes.Pop()
+}
+
+func (g *Game) updatePlaying() {
+	// Автоматическая пауза при потере фокуса окном
+	if g.pauseMenu.autoPause && !ebiten.IsFocused() {
+		g.Pause()
+		return
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) || inpututil.IsKeyJustPressed(ebiten.KeyP) {
+		g.Pause()
+		return
+	}
+
+	// Редактор уровня доступен только в режиме отладки
+	if g.screenManager.debug && inpututil.IsKeyJustPressed(ebiten.KeyF2) {
+		g.scenes.Push(NewEditorScene())
+		return
+	}
+
 	// Обработка ввода
 	g.handleInput()
 
 	// Обновление игрока
 	g.player.Update()
 
-	// Проверка столкновений с врагами
-	for _, enemy := range g.levels[g.currentLevel].Enemies {
-		if g.isColliding(g.player, enemy) {
-			g.player.TakeDamage(enemy.Damage)
-			break // Обрабатываем только одно столкновение за кадр
+	// Физика игрока
+	level := &g.levels[g.currentLevel]
+	g.player.SetWorldBounds(level.PixelSize())
+	g.player.ApplyPhysics(level.TerrainAt(g.player.Center()), level.Blocked)
+	g.playFootsteps()
+
+	// Обновление врагов (на бесконечных картах - только рядом с экраном)
+	for i := range level.Enemies {
+		if !g.simulated(level, &level.Enemies[i]) {
+			continue
+		}
+		level.Enemies[i].Update(g.player.Center(), level, g.Night(level), g.rng.AI)
+		level.Spatial.Update(i, level.Enemies[i].GetCollisionRect())
+	}
+	if dead := level.removeDeadEnemies(); len(dead) > 0 {
+		for _, e := range dead {
+			g.dropLoot(level, e)
 		}
+		level.RebuildSpatial()
 	}
 
+	// Проверка столкновений с врагами и опасной местностью
+	g.checkCollisions()
+	g.checkHazards()
+
+	// Ключи, добыча и зоны триггеров (двери, выход, телепорты...)
+	g.checkKeys()
+	g.checkPickups()
+	g.checkTriggers()
+
 	// Проверка смерти игрока
-	if g.player.health <= 0 {
-		g.gameState = StateGameOver
-	}
+	g.checkPlayerState()
 }
 
 func (g *Game) checkCollisions() {
-	if g.player == nil || g.player.invulnerable || len(g.levels) == 0 || g.currentLevel >= len(g.levels) {
+	g.playerContacts = g.playerContacts[:0]
+	if g.player == nil || len(g.levels) == 0 || g.currentLevel >= len(g.levels) {
 		return
 	}
 
 	level := g.levels[g.currentLevel]
-	playerRect := g.player.GetCollisionRect()
+	g.playerContacts = append(g.playerContacts, level.Spatial.QueryRect(g.player.GetCollisionRect())...)
+
+	// У каждого врага свой кулдаун, поэтому проверяем всех
+	for _, i := range g.playerContacts {
+		enemy := &level.Enemies[i]
+		hit := g.damage.Apply(g.player, DamageSource{
+			Kind:      SourceContact,
+			ID:        i,
+			Amount:    enemy.Damage,
+			Type:      enemy.DamageType,
+			Origin:    enemy.Center(),
+			Knockback: ContactKnockback,
+			Cooldown:  ContactDamageCooldown,
+		})
 
-	for _, enemy := range level.Enemies {
-		enemyRect := enemy.GetCollisionRect()
-		if playerRect.Overlaps(enemyRect) {
-			g.player.TakeDamage(enemy.Damage)
-			break // Обрабатываем только одно столкновение за кадр
+		// Враг отскакивает в противоположную сторону
+		if hit {
+			v := knockbackVector(g.player.Center(), enemy.Center(), ContactKnockback/2)
+			enemy.ApplyKnockback(v)
 		}
 	}
 }
 
+// playFootsteps озвучивает шаги игрока звуком местности
+func (g *Game) playFootsteps() {
+	p := g.player
+	if p.terrain.Footstep == "" || p.body.Speed() < FootstepMinSpeed || gameClock.Since(p.lastStep) < FootstepInterval {
+		return
+	}
+	p.lastStep = gameClock.Now()
+	g.sounds.Play(p.terrain.Footstep)
+}
+
+func (g *Game) checkHazards() {
+	level := g.levels[g.currentLevel]
+	if src, ok := level.HazardAt(g.player.Center()); ok {
+		g.damage.Apply(g.player, src)
+	}
+}
+
+// checkKeys подбирает ключи, которых касается игрок
+func (g *Game) checkKeys() {
+	level := &g.levels[g.currentLevel]
+	rect := g.player.GetCollisionRect()
+	for i := range level.Keys {
+		key := &level.Keys[i]
+		if key.Taken || !rect.Overlaps(tileRect(key.Position)) {
+			continue
+		}
+		key.Taken = true
+		g.player.AddKey(key.ID)
+		g.sounds.Play("key")
+	}
+}
+
+// dropLoot оставляет на месте погибшего врага сердце с шансом LootHeartChance.
+// Бросок идет из потока Loot, чтобы добыча не сдвигала генерацию уровней.
+func (g *Game) dropLoot(level *Level, e Enemy) {
+	if g.rng.Loot.Chance(LootHeartChance) {
+		level.Pickups = append(level.Pickups, Pickup{Position: e.Position, Heal: LootHeartHeal})
+	}
+}
+
+func (g *Game) checkPickups() {
+	level := &g.levels[g.currentLevel]
+	rect := g.player.GetCollisionRect()
+	for i := range level.Pickups {
+		pickup := &level.Pickups[i]
+		if pickup.Taken || !rect.Overlaps(tileRect(pickup.Position)) {
+			continue
+		}
+		pickup.Taken = true
+		g.player.Heal(pickup.Heal)
+		g.sounds.Play("pickup")
+	}
+}
+
+// tileRect возвращает прямоугольник клетки с левым верхним углом pos
+func tileRect(pos Position) image.Rectangle {
+	x, y := int(pos.X), int(pos.Y)
+	return image.Rect(x, y, x+tileSize, y+tileSize)
+}
+
 func (g *Game) checkPlayerState() {
 	if g.player != nil && g.player.health <= 0 {
-		g.gameState = StateGameOver
+		g.scenes.Transition(FadeTransition(color.RGBA{0x44, 0x22, 0x22, 0xFF}), func() {
+			g.scenes.Replace(&GameOverScene{})
+		})
 	}
 }
 
 func (g *Game) handleInput() {
 	g.handleMovementInput()
+	g.handleClickToMove()
 	g.handleRotationInput()
 	g.handleAttackInput()
 
 	// Тестовый урон по нажатию H
 	if inpututil.IsKeyJustPressed(ebiten.KeyH) {
-		g.player.TakeDamage(20)
+		g.damage.Apply(g.player, DamageSource{Kind: SourceDebug, Amount: 20})
 	}
 }
 
@@ -122,11 +331,33 @@ func (g *Game) handleMovementInput() {
 		moving = true
 	}
 
+	// Клавиатура отменяет перемещение по клику
+	if moving {
+		g.player.SetPath(nil)
+	} else if g.player.FollowPath() {
+		moving = true
+	}
+
 	if !moving && !g.player.attacking {
 		g.player.Stop()
 	}
 }
 
+func (g *Game) handleClickToMove() {
+	if !inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		return
+	}
+
+	level := g.levels[g.currentLevel]
+	if level.Nav == nil {
+		return
+	}
+
+	mx, my := g.display.CursorPosition()
+	target := g.camera.ToWorld(Position{X: mx, Y: my})
+	g.player.SetPath(level.Nav.FindPath(g.player.Center(), target))
+}
+
 func (g *Game) handleRotationInput() {
 	if ebiten.IsKeyPressed(ebiten.KeyRight) {
 		g.player.Rotate(RotationSpeed)
@@ -144,13 +375,6 @@ func (g *Game) handleAttackInput() {
 	g.input.lastAttackPress = currentAttackPress
 }
 
-func (g *Game) updateMainMenu() error {
-	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
-		g.gameState = StatePlaying
-	}
-	return nil
-}
-
 func (g *Game) updateGameOver() error {
 	if inpututil.IsKeyJustPressed(ebiten.KeyR) {
 		g.RestartGame()
@@ -158,12 +382,81 @@ func (g *Game) updateGameOver() error {
 	return nil
 }
 
-func (g *Game) RestartGame() {
+// resetWorld заново создает игрока и уровни, не трогая стек сцен
+func (g *Game) resetWorld() {
 	g.player = NewPlayer()
-	g.gameState = StatePlaying
-	g.currentLevel = 0
+	g.player.godMode = g.config.GodMode
+	g.damage.Reset()
+	g.rng.Reset() // Перезапуск дает тот же мир при том же зерне
+
+	// Ошибки загрузки уже в логе и были показаны при запуске
+	g.levels, _ = g.loadLevels()
+	if g.hotReload != nil {
+		g.hotReload.WatchGame(g)
+	}
+	g.currentLevel = g.startLevel()
+	g.spawnPlayer()
+}
+
+// spawnPlayer ставит игрока в стартовую точку текущего уровня, если она задана
+func (g *Game) spawnPlayer() {
+	g.levels[g.currentLevel].resetTriggers()
+	g.screenManager.ShowMessage("", 0)
+	start := g.levels[g.currentLevel].StartPosition
+	if start == (Position{}) {
+		return
+	}
+	g.player.x, g.player.y = start.X, start.Y
+}
+
+func (g *Game) RestartGame() {
+	g.scenes.Transition(FadeTransition(color.RGBA{0, 0, 0, 255}), func() {
+		g.resetWorld()
+		g.scenes.Reset(&PlayingScene{})
+	})
+}
+
+// RestartLevel начинает текущий уровень заново
+func (g *Game) RestartLevel() {
+	level := g.currentLevel
+	g.scenes.Transition(IrisTransition(color.RGBA{0, 0, 0, 255}, g.playerFocus), func() {
+		g.resetWorld()
+		g.currentLevel = level
+		g.spawnPlayer()
+		g.scenes.Reset(&PlayingScene{})
+	})
+}
+
+// ChangeLevel переходит на уровень index с заданной анимацией
+func (g *Game) ChangeLevel(index int, t Transition) {
+	if index < 0 || index >= len(g.levels) {
+		return
+	}
+	g.scenes.Transition(t, func() {
+		g.currentLevel = index
+		g.spawnPlayer()
+		g.player.body.Stop()
+		g.player.SetPath(nil)
+		g.damage.Reset()
+	})
+}
+
+// QuitToMenu сбрасывает игру и возвращает в главное меню
+func (g *Game) QuitToMenu() {
+	g.scenes.Transition(CrossfadeTransition(), func() {
+		g.resetWorld()
+		g.scenes.Reset(NewMainMenu())
+	})
+}
+
+// playerFocus - центр диафрагмы для переходов вокруг игрока
+func (g *Game) playerFocus() Position {
+	return g.camera.ToScreen(g.player.Center())
 }
 
+// Layout возвращает размер окна в пикселях устройства: масштабирование
+// и полосы по краям выполняет Display.Present
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return WinWidth, WinHeight
+	scale := ebiten.Monitor().DeviceScaleFactor()
+	return int(float64(outsideWidth) * scale), int(float64(outsideHeight) * scale)
 }
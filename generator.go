@@ -0,0 +1,248 @@
+package main
+
+import (
+	"image/color"
+	"math"
+)
+
+// ForestParams - параметры процедурной генерации лесного уровня
+type ForestParams struct {
+	Width, Height int
+	Seed          int64
+	EnemyDensity  float64 // Вероятность врага на каждом доступном тайле
+	SafeRadius    int     // Радиус вокруг старта без врагов (в тайлах)
+}
+
+func DefaultForestParams(seed int64) ForestParams {
+	return ForestParams{
+		Width:        48,
+		Height:       32,
+		Seed:         seed,
+		EnemyDensity: 0.01,
+		SafeRadius:   6,
+	}
+}
+
+// Цвета тайлов для уровней без графики тайлсета
+var tileColors = map[TileType]color.RGBA{
+	TileGrass: {100, 200, 50, 255},
+	TileWater: {50, 100, 200, 255},
+	TileTree:  {0, 100, 0, 255},
+	TileStone: {140, 140, 150, 255},
+	TileSand:  {230, 210, 140, 255},
+	TileWall:  {60, 55, 65, 255},
+	TileFloor: {170, 160, 150, 255},
+}
+
+// Пороги высоты и влажности для биомов
+const (
+	waterLevel     = 0.35
+	sandLevel      = 0.42
+	stoneLevel     = 0.70
+	treeMoisture   = 0.58
+	noiseScale     = 1.0 / 10 // Частота шума в тайлах
+	noiseOctaves   = 4
+	carveTreeCost  = 4 // Стоимость прорубания леса при прокладке пути
+	carveWaterCost = 8 // Стоимость засыпки воды при прокладке пути
+)
+
+// GenerateForest создает лесной уровень из шума. Старт и выход всегда
+// соединены проходимым путем, враги ставятся только на доступные тайлы.
+func GenerateForest(p ForestParams) Level {
+	rng := NewRNG(p.Seed).Level
+	elevation := valueNoise{seed: rng.Uint64()}
+	moisture := valueNoise{seed: rng.Uint64()}
+
+	tiles := make([][]TileType, p.Height)
+	for y := range tiles {
+		tiles[y] = make([]TileType, p.Width)
+		for x := range tiles[y] {
+			e := elevation.fbm(float64(x)*noiseScale, float64(y)*noiseScale, noiseOctaves)
+			m := moisture.fbm(float64(x)*noiseScale, float64(y)*noiseScale, noiseOctaves)
+			tiles[y][x] = biome(e, m)
+		}
+	}
+
+	// Старт у левого края, выход у правого
+	start := nearestWalkable(tiles, TilePoint{1, p.Height / 2})
+	exit := nearestWalkable(tiles, TilePoint{p.Width - 2, p.Height / 2})
+	carvePath(tiles, start, exit)
+
+	l := Level{
+		Name:          "Generated Forest",
+		Map:           tiles,
+		StartPosition: tileTopLeft(start),
+		ExitPosition:  tileTopLeft(exit),
+		Background:    tileColors[TileGrass],
+		Width:         p.Width,
+		Height:        p.Height,
+	}
+
+	l.Enemies = placeEnemies(tiles, start, p, rng)
+	l.prepare()
+	return l
+}
+
+func biome(elevation, moisture float64) TileType {
+	switch {
+	case elevation < waterLevel:
+		return TileWater
+	case elevation < sandLevel:
+		return TileSand
+	case elevation > stoneLevel:
+		return TileStone
+	case moisture > treeMoisture:
+		return TileTree
+	default:
+		return TileGrass
+	}
+}
+
+func isWalkableTile(t TileType) bool {
+	return tileMoveCost[t] > 0
+}
+
+func tileTopLeft(p TilePoint) Position {
+	return Position{X: float64(p.X * tileSize), Y: float64(p.Y * tileSize)}
+}
+
+// nearestWalkable ищет ближайший к from проходимый тайл.
+// Если таких нет, превращает from в траву.
+func nearestWalkable(tiles [][]TileType, from TilePoint) TilePoint {
+	h, w := len(tiles), len(tiles[0])
+	for r := 0; r < w+h; r++ {
+		for dy := -r; dy <= r; dy++ {
+			for dx := -r; dx <= r; dx++ {
+				if absInt(dx) != r && absInt(dy) != r {
+					continue // Только периметр квадрата радиуса r
+				}
+				x, y := from.X+dx, from.Y+dy
+				if x >= 0 && y >= 0 && x < w && y < h && isWalkableTile(tiles[y][x]) {
+					return TilePoint{x, y}
+				}
+			}
+		}
+	}
+	tiles[from.Y][from.X] = TileGrass
+	return from
+}
+
+// carvePath прокладывает самый дешевый путь от start до goal через лес
+// и воду и делает его проходимым
+func carvePath(tiles [][]TileType, start, goal TilePoint) {
+	h, w := len(tiles), len(tiles[0])
+	grid := &NavGrid{Width: w, Height: h, TileWidth: tileSize, TileHeight: tileSize, cost: make([]float64, w*h)}
+	for y := range tiles {
+		for x, t := range tiles[y] {
+			switch t {
+			case TileTree:
+				grid.cost[y*w+x] = carveTreeCost
+			case TileWater:
+				grid.cost[y*w+x] = carveWaterCost
+			default:
+				grid.cost[y*w+x] = tileMoveCost[t]
+			}
+		}
+	}
+
+	path := NewPathfinder(grid, false).FindTilePath(start, goal)
+	for _, p := range path {
+		switch tiles[p.Y][p.X] {
+		case TileTree:
+			tiles[p.Y][p.X] = TileGrass
+		case TileWater:
+			tiles[p.Y][p.X] = TileSand // Брод
+		}
+	}
+}
+
+// reachableFrom возвращает маску тайлов, доступных из start
+func reachableFrom(tiles [][]TileType, start TilePoint) [][]bool {
+	h, w := len(tiles), len(tiles[0])
+	seen := make([][]bool, h)
+	for y := range seen {
+		seen[y] = make([]bool, w)
+	}
+
+	queue := []TilePoint{start}
+	seen[start.Y][start.X] = true
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, step := range orthogonalSteps {
+			x, y := cur.X+step.X, cur.Y+step.Y
+			if x < 0 || y < 0 || x >= w || y >= h || seen[y][x] || !isWalkableTile(tiles[y][x]) {
+				continue
+			}
+			seen[y][x] = true
+			queue = append(queue, TilePoint{x, y})
+		}
+	}
+	return seen
+}
+
+// placeEnemies расставляет врагов с заданной плотностью на доступных
+// тайлах вне безопасной зоны вокруг старта
+func placeEnemies(tiles [][]TileType, start TilePoint, p ForestParams, rng *RandStream) []Enemy {
+	reachable := reachableFrom(tiles, start)
+	kinds := SpawnableEnemyTypes()
+
+	var enemies []Enemy
+	for y := range tiles {
+		for x := range tiles[y] {
+			if !reachable[y][x] {
+				continue
+			}
+			if absInt(x-start.X) <= p.SafeRadius && absInt(y-start.Y) <= p.SafeRadius {
+				continue
+			}
+			if !rng.Chance(p.EnemyDensity) {
+				continue
+			}
+			kind := kinds[rng.Intn(len(kinds))]
+			enemies = append(enemies, NewEnemy(kind, tileTopLeft(TilePoint{x, y})))
+		}
+	}
+	return enemies
+}
+
+// valueNoise - двумерный шум значений с интерполяцией
+type valueNoise struct {
+	seed uint64
+}
+
+// lattice возвращает псевдослучайное значение в узле сетки
+func (n valueNoise) lattice(x, y int) float64 {
+	h := n.seed ^ uint64(int64(x))*0x9E3779B97F4A7C15 ^ uint64(int64(y))*0xC2B2AE3D27D4EB4F
+	return newRandStream(h).Float64()
+}
+
+func (n valueNoise) at(x, y float64) float64 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	tx, ty := smoothstep(x-x0), smoothstep(y-y0)
+	ix, iy := int(x0), int(y0)
+
+	top := lerp(n.lattice(ix, iy), n.lattice(ix+1, iy), tx)
+	bottom := lerp(n.lattice(ix, iy+1), n.lattice(ix+1, iy+1), tx)
+	return lerp(top, bottom, ty)
+}
+
+// fbm складывает несколько октав шума, результат в диапазоне [0, 1)
+func (n valueNoise) fbm(x, y float64, octaves int) float64 {
+	sum, amplitude, norm := 0.0, 1.0, 0.0
+	for i := 0; i < octaves; i++ {
+		sum += n.at(x, y) * amplitude
+		norm += amplitude
+		amplitude /= 2
+		x, y = x*2, y*2
+	}
+	return sum / norm
+}
+
+func smoothstep(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
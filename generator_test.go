@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// testTiles строит карту тайлов из строк: 'T' - дерево, 'w' - вода,
+// остальное - трава
+func testTiles(rows []string) [][]TileType {
+	tiles := make([][]TileType, len(rows))
+	for y, row := range rows {
+		tiles[y] = make([]TileType, len(row))
+		for x, c := range row {
+			switch c {
+			case 'T':
+				tiles[y][x] = TileTree
+			case 'w':
+				tiles[y][x] = TileWater
+			default:
+				tiles[y][x] = TileGrass
+			}
+		}
+	}
+	return tiles
+}
+
+func TestCarvePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    []string
+		start   TilePoint
+		goal    TilePoint
+		changed map[TilePoint]TileType // Клетки, которые путь должен изменить
+	}{
+		{
+			name:    "open field is left alone",
+			rows:    []string{".....", "....."},
+			start:   TilePoint{0, 0},
+			goal:    TilePoint{4, 1},
+			changed: map[TilePoint]TileType{},
+		},
+		{
+			name:    "tree wall is cut",
+			rows:    []string{"..T..", "..T..", "..T.."},
+			start:   TilePoint{0, 1},
+			goal:    TilePoint{4, 1},
+			changed: map[TilePoint]TileType{{2, 1}: TileGrass},
+		},
+		{
+			name:    "river gets a ford",
+			rows:    []string{"..w..", "..w.."},
+			start:   TilePoint{0, 0},
+			goal:    TilePoint{4, 0},
+			changed: map[TilePoint]TileType{{2, 0}: TileSand},
+		},
+		{
+			name:    "cutting a tree is cheaper than filling water",
+			rows:    []string{"..T..", "..w.."},
+			start:   TilePoint{0, 1},
+			goal:    TilePoint{4, 1},
+			changed: map[TilePoint]TileType{{2, 0}: TileGrass},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiles := testTiles(tt.rows)
+			before := testTiles(tt.rows)
+			carvePath(tiles, tt.start, tt.goal)
+
+			if !reachableFrom(tiles, tt.start)[tt.goal.Y][tt.goal.X] {
+				t.Fatalf("goal %v is not reachable from %v after carving", tt.goal, tt.start)
+			}
+			for y := range tiles {
+				for x := range tiles[y] {
+					p := TilePoint{x, y}
+					want, ok := tt.changed[p]
+					if !ok {
+						want = before[y][x]
+					}
+					if tiles[y][x] != want {
+						t.Errorf("tile %v = %v, want %v", p, tiles[y][x], want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateForestConnected(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		t.Run(fmt.Sprintf("seed=%d", seed), func(t *testing.T) {
+			l := GenerateForest(DefaultForestParams(seed))
+			start := TilePoint{int(l.StartPosition.X) / tileSize, int(l.StartPosition.Y) / tileSize}
+			exit := TilePoint{int(l.ExitPosition.X) / tileSize, int(l.ExitPosition.Y) / tileSize}
+			reachable := reachableFrom(l.Map, start)
+			if !reachable[exit.Y][exit.X] {
+				t.Fatalf("exit %v is not reachable from start %v", exit, start)
+			}
+			for _, e := range l.Enemies {
+				x, y := int(e.Position.X)/tileSize, int(e.Position.Y)/tileSize
+				if !reachable[y][x] {
+					t.Errorf("%s at (%d, %d) is not reachable from start", e.Type, x, y)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateForestSeed(t *testing.T) {
+	a := GenerateForest(DefaultForestParams(42))
+	b := GenerateForest(DefaultForestParams(42))
+	if !reflect.DeepEqual(a.Map, b.Map) {
+		t.Error("same seed gave different tiles")
+	}
+	if a.StartPosition != b.StartPosition || a.ExitPosition != b.ExitPosition {
+		t.Errorf("same seed: start %v and %v, exit %v and %v",
+			a.StartPosition, b.StartPosition, a.ExitPosition, b.ExitPosition)
+	}
+	if len(a.Enemies) != len(b.Enemies) {
+		t.Fatalf("same seed gave %d and %d enemies", len(a.Enemies), len(b.Enemies))
+	}
+	for i := range a.Enemies {
+		if a.Enemies[i].Type != b.Enemies[i].Type || a.Enemies[i].Position != b.Enemies[i].Position {
+			t.Errorf("enemy %d: %s at %v and %s at %v", i,
+				a.Enemies[i].Type, a.Enemies[i].Position, b.Enemies[i].Type, b.Enemies[i].Position)
+		}
+	}
+
+	if c := GenerateForest(DefaultForestParams(43)); reflect.DeepEqual(a.Map, c.Map) {
+		t.Error("seeds 42 and 43 gave the same tiles")
+	}
+}
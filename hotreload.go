@@ -0,0 +1,201 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// fileStamp - то, по чему опрос замечает изменение файла
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// FileWatcher опрашивает время изменения файлов. Опрос вместо событий
+// ОС проще и одинаково работает на всех платформах.
+type FileWatcher struct {
+	files map[string]fileStamp
+}
+
+func NewFileWatcher() *FileWatcher {
+	return &FileWatcher{files: make(map[string]fileStamp)}
+}
+
+// Watch добавляет файлы в наблюдение. Отсутствующие файлы тоже
+// наблюдаются: их появление считается изменением.
+func (w *FileWatcher) Watch(paths ...string) {
+	for _, path := range paths {
+		if _, ok := w.files[path]; !ok {
+			w.files[path] = statFile(path)
+		}
+	}
+}
+
+// Poll возвращает файлы, изменившиеся с прошлого опроса
+func (w *FileWatcher) Poll() []string {
+	var changed []string
+	for path, old := range w.files {
+		cur := statFile(path)
+		// Пропавший файл обычно значит, что редактор его как раз перезаписывает
+		if cur == (fileStamp{}) || cur == old {
+			continue
+		}
+		w.files[path] = cur
+		changed = append(changed, path)
+	}
+	return changed
+}
+
+func statFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
+
+// HotReloader в режиме разработки (-dev) перезагружает измененные карты,
+// тайлсеты и спрайты, не перезапуская игру
+type HotReloader struct {
+	watcher  *FileWatcher
+	lastPoll time.Time
+
+	// Последнее сообщение для экрана. Ошибка висит до успешной перезагрузки.
+	message   string
+	isError   bool
+	messageAt time.Time
+}
+
+func NewHotReloader() *HotReloader {
+	return &HotReloader{watcher: NewFileWatcher()}
+}
+
+// WatchGame добавляет в наблюдение спрайты и файлы всех уровней
+func (h *HotReloader) WatchGame(g *Game) {
+	h.watcher.Watch(characterSpritePaths...)
+	for _, level := range g.levels {
+		h.watcher.Watch(level.Files...)
+	}
+}
+
+// Update раз в HotReloadInterval проверяет файлы и перезагружает измененные
+func (h *HotReloader) Update(g *Game) {
+	if time.Since(h.lastPoll) < HotReloadInterval {
+		return
+	}
+	h.lastPoll = time.Now()
+
+	// Пока открыт редактор, уровень меняется в памяти: ждем выхода из него
+	if _, editing := g.scenes.Top().(*EditorScene); editing {
+		return
+	}
+
+	for _, path := range h.watcher.Poll() {
+		h.reload(g, path)
+	}
+}
+
+func (h *HotReloader) reload(g *Game, path string) {
+	if _, ok := characterSpriteIndex[path]; ok {
+		if err := ReloadSprite(path); err != nil {
+			h.fail(fmt.Errorf("sprite %s: %v", path, err))
+			return
+		}
+		h.succeed("Reloaded sprite " + filepath.Base(path))
+	}
+
+	for i := range g.levels {
+		level := &g.levels[i]
+		if !containsPath(level.Files, path) {
+			continue
+		}
+		if path == level.Source {
+			h.reloadLevel(g, i)
+		} else {
+			h.reloadTilesets(level)
+		}
+	}
+}
+
+// reloadLevel заново загружает карту. Игрок остается на месте, враги,
+// ключи и двери берутся из новой карты.
+func (h *HotReloader) reloadLevel(g *Game, index int) {
+	old := &g.levels[index]
+	level, err := loadTiledLevel(old.Source)
+	if err != nil {
+		h.fail(err)
+		return
+	}
+
+	g.levels[index] = *level
+	h.watcher.Watch(level.Files...)
+	if index == g.currentLevel {
+		g.playerContacts = g.playerContacts[:0]
+		g.player.SetPath(nil)
+	}
+	h.reportWarnings("Reloaded "+filepath.Base(level.Source), level.Warnings)
+}
+
+// reloadTilesets обновляет тайлы и картинки слоев после изменения TSX или PNG
+func (h *HotReloader) reloadTilesets(level *Level) {
+	images, terrain, files, warnings := loadTilesetImages(level.TiledMap, level.Source)
+	layerImages, layerFiles, layerWarnings := loadLayerImages(level.TiledMap, level.Source)
+	files = append(files, layerFiles...)
+	warnings = append(warnings, layerWarnings...)
+	level.TileImages = images
+	level.TileTerrain = terrain
+	level.LayerImages = layerImages
+	level.InvalidateTiles()
+	level.Files = append([]string{level.Source}, files...)
+	level.Warnings = warnings
+	h.watcher.Watch(files...)
+	h.reportWarnings("Reloaded tilesets of "+filepath.Base(level.Source), warnings)
+}
+
+func (h *HotReloader) reportWarnings(success string, warnings []error) {
+	if len(warnings) > 0 {
+		msgs := make([]string, len(warnings))
+		for i, w := range warnings {
+			msgs[i] = w.Error()
+		}
+		h.fail(errors.New(strings.Join(msgs, "; ")))
+		return
+	}
+	h.succeed(success)
+}
+
+func (h *HotReloader) succeed(msg string) {
+	log.Print(msg)
+	h.message, h.isError, h.messageAt = msg, false, time.Now()
+}
+
+func (h *HotReloader) fail(err error) {
+	log.Printf("Hot reload failed: %v", err)
+	h.message, h.isError, h.messageAt = "Reload failed: "+err.Error(), true, time.Now()
+}
+
+// Message возвращает сообщение для экрана: ошибки до исправления,
+// остальное - несколько секунд
+func (h *HotReloader) Message() (string, bool) {
+	if h == nil || h.message == "" {
+		return "", false
+	}
+	if !h.isError && time.Since(h.messageAt) > 3*time.Second {
+		return "", false
+	}
+	return h.message, h.isError
+}
+
+func containsPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// Parallax возвращает коэффициенты параллакса слоя (Tiled не пишет 1)
+func (l *Layer) Parallax() (float64, float64) {
+	x, y := 1.0, 1.0
+	if l.ParallaxX != nil {
+		x = *l.ParallaxX
+	}
+	if l.ParallaxY != nil {
+		y = *l.ParallaxY
+	}
+	return x, y
+}
+
+// AllLayers возвращает слои карты без групп: содержимое групп идет
+// на месте группы, в порядке отрисовки
+func (m *TiledMap) AllLayers() []Layer {
+	var out []Layer
+	var walk func(layers []Layer)
+	walk = func(layers []Layer) {
+		for _, layer := range layers {
+			if layer.Type == "group" {
+				walk(layer.Layers)
+				continue
+			}
+			out = append(out, layer)
+		}
+	}
+	walk(m.Layers)
+	return out
+}
+
+// layerStyle - итоговые параметры отрисовки слоя с учетом всех его групп
+type layerStyle struct {
+	offset   Position
+	parallax Position
+	opacity  float64
+	tint     [4]float32 // Множители RGBA
+	depth    string
+}
+
+var defaultLayerStyle = layerStyle{
+	parallax: Position{X: 1, Y: 1},
+	opacity:  1,
+	tint:     [4]float32{1, 1, 1, 1},
+}
+
+// styledLayer - слой вместе с параметрами, унаследованными от групп
+type styledLayer struct {
+	Layer
+	style layerStyle
+	index int // Номер среди видимых слоев карты, ключ TileCache
+}
+
+// visibleLayers разворачивает группы в список видимых слоев. Смещения
+// групп складываются, а параллакс, прозрачность и оттенок перемножаются,
+// как в Tiled. Глубина (свойство "depth") наследуется от группы.
+func visibleLayers(layers []Layer, parent layerStyle) []styledLayer {
+	var out []styledLayer
+	for _, layer := range layers {
+		if !layer.Visible {
+			continue
+		}
+		style := parent
+		px, py := layer.Parallax()
+		style.offset.X += layer.OffsetX
+		style.offset.Y += layer.OffsetY
+		style.parallax.X *= px
+		style.parallax.Y *= py
+		style.opacity *= layer.Opacity
+		if tint, ok := parseTintColor(layer.TintColor); ok {
+			for i := range style.tint {
+				style.tint[i] *= tint[i]
+			}
+		}
+		// Группа без своего "depth" оставляет детям глубину по их типу
+		if _, ok := propertyString(layer.Properties, "depth"); ok || (style.depth == "" && layer.Type != "group") {
+			style.depth = layerDepth(layer)
+		}
+
+		if layer.Type == "group" {
+			out = append(out, visibleLayers(layer.Layers, style)...)
+			continue
+		}
+		out = append(out, styledLayer{Layer: layer, style: style})
+	}
+	return out
+}
+
+// parseTintColor разбирает цвет Tiled "#RRGGBB" или "#AARRGGBB"
+func parseTintColor(s string) ([4]float32, bool) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 && len(s) != 8 {
+		return [4]float32{}, false
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return [4]float32{}, false
+	}
+	a := uint64(0xFF)
+	if len(s) == 8 {
+		a = v >> 24
+	}
+	return [4]float32{
+		float32(v>>16&0xFF) / 255,
+		float32(v>>8&0xFF) / 255,
+		float32(v&0xFF) / 255,
+		float32(a) / 255,
+	}, true
+}
+
+// shift - сдвиг слоя в мировых координатах: смещение плюс параллакс.
+// Слой с параллаксом 0 стоит на месте относительно экрана, 1 - движется с миром.
+func (s *layerStyle) shift(c *Camera) Position {
+	return Position{
+		X: s.offset.X + math.Round(c.X)*(1-s.parallax.X),
+		Y: s.offset.Y + math.Round(c.Y)*(1-s.parallax.Y),
+	}
+}
+
+// apply переводит спрайт из мировых координат в экранные с учетом
+// сдвига слоя и добавляет прозрачность и оттенок
+func (s *layerStyle) apply(op *ebiten.DrawImageOptions, c *Camera) {
+	shift := s.shift(c)
+	op.GeoM.Translate(shift.X, shift.Y)
+	c.Apply(op)
+	op.ColorScale.Scale(s.tint[0], s.tint[1], s.tint[2], 1)
+	op.ColorScale.ScaleAlpha(s.tint[3] * float32(s.opacity))
+}
+
+// drawImageLayer рисует картинку слоя imagelayer; repeatx/repeaty
+// размножают ее на весь экран
+func (g *Game) drawImageLayer(screen *ebiten.Image, level *Level, layer styledLayer) {
+	img, ok := level.LayerImages[layer.Image]
+	if !ok {
+		return
+	}
+	w, h := float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+
+	// Экранная позиция картинки без повторов
+	shift := layer.style.shift(&g.camera)
+	origin := g.camera.ToScreen(shift)
+
+	// Первая и последняя копии, попадающие на экран
+	x0, x1, y0, y1 := 0.0, 0.0, 0.0, 0.0
+	sw, sh := float64(screen.Bounds().Dx()), float64(screen.Bounds().Dy())
+	if layer.RepeatX {
+		x0, x1 = -math.Ceil(origin.X/w), math.Floor((sw-origin.X)/w)
+	}
+	if layer.RepeatY {
+		y0, y1 = -math.Ceil(origin.Y/h), math.Floor((sh-origin.Y)/h)
+	}
+
+	for iy := y0; iy <= y1; iy++ {
+		for ix := x0; ix <= x1; ix++ {
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(ix*w, iy*h)
+			layer.style.apply(op, &g.camera)
+			screen.DrawImage(img, op)
+		}
+	}
+}
+
+// loadLayerImages загружает картинки слоев imagelayer, в том числе внутри
+// групп. Ключ - путь из карты, как в Layer.Image.
+func loadLayerImages(tiledMap *TiledMap, mapPath string) (images map[string]*ebiten.Image, files []string, warnings []error) {
+	images = make(map[string]*ebiten.Image)
+	for _, layer := range tiledMap.AllLayers() {
+		if layer.Type != "imagelayer" || layer.Image == "" {
+			continue
+		}
+		if _, ok := images[layer.Image]; ok {
+			continue
+		}
+		path := filepath.Join(filepath.Dir(mapPath), layer.Image)
+		files = append(files, path)
+		img, _, err := ebitenutil.NewImageFromFile(path)
+		if err != nil {
+			warnings = append(warnings, assetError(AssetImage, path, false, fmt.Errorf("image layer %q: %w", layer.Name, err)))
+			continue
+		}
+		images[layer.Image] = img
+	}
+	return images, files, warnings
+}
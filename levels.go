@@ -3,14 +3,15 @@ package main
 import (
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
 	"io/ioutil"
 	"log"
-	"math/rand"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
@@ -25,45 +26,107 @@ const (
 	TileTree
 	TileStone
 	TileSand
+	TileWall
+	TileFloor
 )
 
+var tileTypeNames = map[TileType]string{
+	TileGrass: "grass",
+	TileWater: "water",
+	TileTree:  "tree",
+	TileStone: "stone",
+	TileSand:  "sand",
+	TileWall:  "wall",
+	TileFloor: "floor",
+}
+
+func (t TileType) String() string {
+	if name, ok := tileTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("tile(%d)", int(t))
+}
+
 type Position struct {
 	X, Y float64
 }
 
 type Enemy struct {
-	Type     string
-	Health   int
-	Position Position
-	Speed    float64
-	Damage   int
-	Sprite   *ebiten.Image
+	Type       string
+	Health     int
+	Position   Position
+	Speed      float64
+	Damage     int
+	DamageType DamageType
+	Sprite     *ebiten.Image
+
+	Patrol   PatrolRoute // Маршрут обхода из линии Tiled (свойство "patrol")
+	ObjectID int         // Объект Tiled, из которого создан враг (0 - добавлен в редакторе)
+
+	// Навигация и физика
+	path        []Position
+	lastRepath  time.Time
+	repathDelay time.Duration // Интервал до следующего пересчета, с разбросом из потока AI
+	body        Body
+	patrolIndex int // Следующая точка маршрута
+	patrolStep  int // Направление обхода незамкнутого маршрута: 1 или -1
+
+	terrain        Terrain // Местность под врагом на последнем тике
+	lastTerrainHit time.Time
+}
+
+// PatrolRoute - точки обхода врага. Незамкнутая линия проходится туда
+// и обратно, многоугольник - по кругу.
+type PatrolRoute struct {
+	ObjectID int // Объект Tiled, из которого взят маршрут
+	Points   []Position
+	Loop     bool
 }
 
 // TiledMap представляет структуру карты из Tiled
 type TiledMap struct {
-	Width      int     `json:"width"`
-	Height     int     `json:"height"`
-	TileWidth  int     `json:"tilewidth"`
-	TileHeight int     `json:"tileheight"`
-	Layers     []Layer `json:"layers"`
-	Tilesets   []struct {
-		FirstGID int    `json:"firstgid"`
-		Source   string `json:"source"`
-		// Убрали Image, так как его нет в JSON
-	} `json:"tilesets"`
+	Width      int          `json:"width"`
+	Height     int          `json:"height"`
+	TileWidth  int          `json:"tilewidth"`
+	TileHeight int          `json:"tileheight"`
+	Layers     []Layer      `json:"layers"`
+	Tilesets   []TilesetRef `json:"tilesets"`
+	Infinite   bool         `json:"infinite"` // Тайлы слоев хранятся кусками (Layer.Chunks)
+	Properties []Property   `json:"properties,omitempty"`
+}
+
+// TilesetRef - ссылка на внешний TSX тайлсет
+type TilesetRef struct {
+	FirstGID int    `json:"firstgid"`
+	Source   string `json:"source"`
+	// Убрали Image, так как его нет в JSON
 }
 
 type Layer struct {
 	Name       string     `json:"name"`
-	Data       []int      `json:"data"`
+	Data       []int      `json:"data,omitempty"`
 	Width      int        `json:"width"`
 	Height     int        `json:"height"`
 	Type       string     `json:"type"`
 	Opacity    float64    `json:"opacity"`
 	Visible    bool       `json:"visible"`
-	Objects    []Object   `json:"objects"`
-	Properties []Property `json:"properties"`
+	Objects    []Object   `json:"objects,omitempty"`
+	Properties []Property `json:"properties,omitempty"`
+
+	Layers    []Layer  `json:"layers,omitempty"` // Дочерние слои группы (type "group")
+	Image     string   `json:"image,omitempty"`  // Картинка слоя imagelayer относительно карты
+	RepeatX   bool     `json:"repeatx,omitempty"`
+	RepeatY   bool     `json:"repeaty,omitempty"`
+	OffsetX   float64  `json:"offsetx,omitempty"`
+	OffsetY   float64  `json:"offsety,omitempty"`
+	ParallaxX *float64 `json:"parallaxx,omitempty"` // nil - 1, см. Parallax
+	ParallaxY *float64 `json:"parallaxy,omitempty"`
+	TintColor string   `json:"tintcolor,omitempty"` // #RRGGBB или #AARRGGBB
+
+	Chunks []Chunk `json:"chunks,omitempty"` // Тайлы бесконечной карты вместо Data
+	StartX int     `json:"startx,omitempty"`
+	StartY int     `json:"starty,omitempty"`
+	chunks *chunkIndex
 }
 
 type Object struct {
@@ -76,7 +139,17 @@ type Object struct {
 	Type       string     `json:"type"`
 	Name       string     `json:"name"`
 	Rotation   float64    `json:"rotation"`
-	Properties []Property `json:"properties"`
+	Polygon    []Point    `json:"polygon,omitempty"`  // Вершины относительно X, Y
+	Polyline   []Point    `json:"polyline,omitempty"` // Незамкнутая линия относительно X, Y
+	Ellipse    bool       `json:"ellipse,omitempty"`
+	Point      bool       `json:"point,omitempty"`
+	Properties []Property `json:"properties,omitempty"`
+}
+
+// Point - вершина полигона Tiled
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
 }
 
 type Property struct {
@@ -95,15 +168,64 @@ type TSX struct {
 
 type Level struct {
 	Name          string
+	Source        string       // Файл карты Tiled, из которого загружен уровень
+	Files         []string     // Все файлы уровня: карта, тайлсеты, изображения
+	Warnings      []error      // Некритичные ошибки загрузки
 	TiledMap      *TiledMap    // Для уровней из Tiled
 	Map           [][]TileType // Для ручной генерации уровней
 	TileImages    map[int]*ebiten.Image
+	LayerImages   map[string]*ebiten.Image // Картинки слоев imagelayer по Layer.Image
+	TileTerrain   map[int]Terrain          // Местность из свойств тайлов TSX по GID
 	Enemies       []Enemy
 	StartPosition Position
+	ExitPosition  Position // Выход с уровня (для сгенерированных уровней)
 	Background    color.RGBA
 	MusicTrack    string
 	Width         int
 	Height        int
+	Nav           *Pathfinder  // Поиск путей по сетке уровня
+	Spatial       *SpatialHash // Пространственный индекс врагов
+	Doors         []Door
+	Keys          []Key
+	Pickups       []Pickup           // Добыча с погибших врагов, в сейв карты не попадает
+	Triggers      []Trigger          // Триггеры из объектов Tiled
+	Colliders     []Shape            // Стены произвольной формы: объекты "collision" или со свойством "collides"
+	Streaming     bool               // Бесконечная карта: враги вдали от экрана не обновляются
+	Lights        []Light            // Факелы и другие источники света
+	Dark          bool               // Подземелье: всегда ночь, свет только от источников
+	tiles         *TileCache         // Собранные тайловые слои, см. drawCachedLayer
+	triggers      []Trigger          // Активные триггеры: из карты, двери и выход (см. buildTriggers)
+	colliderTiles map[TilePoint]bool // Клетки, закрытые для поиска пути только из-за Colliders
+	authored      *Level             // Уровень до начала игры: его правит и сохраняет редактор
+	terrainLayers []*Layer           // Тайловые слои сверху вниз, см. tileLayersTopDown
+}
+
+// Door - запертая дверь в клетке уровня, открывается ключом KeyID
+type Door struct {
+	Tile     TilePoint
+	KeyID    int
+	Open     bool
+	ObjectID int // Объект Tiled двери (0 - сгенерирована)
+}
+
+// Key - ключ, лежащий на уровне
+type Key struct {
+	ID       int
+	Position Position
+	Taken    bool
+	ObjectID int // Объект Tiled ключа (0 - сгенерирован или добавлен в редакторе)
+}
+
+// Pickup - предмет, выпавший из врага. Подбирается касанием.
+type Pickup struct {
+	Position Position
+	Heal     int
+	Taken    bool
+}
+
+// FootY - нижний край спрайта врага для сортировки по глубине
+func (e *Enemy) FootY() float64 {
+	return e.Position.Y + EnemySpriteHeight
 }
 
 func (e *Enemy) GetCollisionRect() image.Rectangle {
@@ -122,67 +244,56 @@ func (e *Enemy) GetCollisionRect() image.Rectangle {
 	)
 }
 
-// CreateLevels создает все уровни игры
-func CreateLevels() []Level {
-	levels := make([]Level, 0)
-
-	// Проверка существования директории
-	if _, err := os.Stat("data/maps/forest"); os.IsNotExist(err) {
-		log.Printf("Directory 'data/maps/forest' does not exist")
-		return append(levels, createForestLevel())
-	}
-
-	// Загружаем уровень из Tiled
-	// Используйте абсолютный путь для тестирования
-	absPath, err := filepath.Abs("data/maps/forest/forest.json")
-	if err != nil {
-		log.Fatal(err)
-	}
-	log.Println("Trying to load:", absPath)
-
-	tiledLevel, err := loadTiledLevel(absPath)
-	if err != nil {
-		// Если не удалось загрузить, создаем дефолтный уровень
-		levels = append(levels, createForestLevel())
-		log.Print("Not loaded")
-	} else {
-		log.Print("Loaded")
-		levels = append(levels, *tiledLevel)
-	}
-
-	return levels
+// LevelManifest - карты Tiled, из которых собирается игра, по порядку.
+// Их же проверяет подкоманда validate.
+var LevelManifest = []string{
+	"data/maps/forest/forest.json",
 }
 
-// loadTiledLevel загружает уровень из Tiled JSON
-func loadTiledLevel(path string) (*Level, error) {
-	absPath, _ := filepath.Abs(path)
-	log.Printf("Loading level from: %s", absPath)
+// CreateLevels создает все уровни игры. Случайность берется из rng,
+// чтобы уровни воспроизводились по зерну. Вместо карты, которую не удалось
+// загрузить, ставится процедурный лес, а ошибка возвращается вызывающему.
+func CreateLevels(rng *RandStream) ([]Level, []error) {
+	levels := make([]Level, 0)
+	var problems []error
 
-	file, err := ioutil.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %v", err)
+	for _, path := range LevelManifest {
+		tiledLevel, err := loadTiledLevel(path)
+		if err != nil {
+			var ae *AssetError
+			if errors.As(err, &ae) && ae.Kind == AssetMap {
+				ae.Required = true
+			}
+			log.Printf("Level %s not loaded, using a generated forest: %v", path, err)
+			problems = append(problems, err)
+			levels = append(levels, createForestLevel(rng))
+			continue
+		}
+		problems = append(problems, tiledLevel.Warnings...)
+		levels = append(levels, *tiledLevel)
 	}
 
-	var tiledMap TiledMap
-	if err := json.Unmarshal(file, &tiledMap); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %v", err)
-	}
+	// После леса - подземелье
+	levels = append(levels, createDungeonLevel(rng))
 
-	// Проверка обязательных полей
-	if tiledMap.Width == 0 || tiledMap.Height == 0 {
-		return nil, fmt.Errorf("invalid map dimensions")
-	}
+	return levels, problems
+}
 
-	tileImages := make(map[int]*ebiten.Image)
+// loadTilesetImages нарезает тайлы из TSX-тайлсетов карты. Возвращает тайлы
+// и местность из свойств тайлов по GID, файлы тайлсетов и их изображений,
+// и проблемы, не мешающие загрузке.
+func loadTilesetImages(tiledMap *TiledMap, mapPath string) (images map[int]*ebiten.Image, terrain map[int]Terrain, files []string, warnings []error) {
+	images = make(map[int]*ebiten.Image)
+	props := make(map[int][]Property)
 
 	for _, tileset := range tiledMap.Tilesets {
-		tsxPath := filepath.Join(filepath.Dir(path), tileset.Source)
+		tsxPath := filepath.Join(filepath.Dir(mapPath), tileset.Source)
+		files = append(files, tsxPath)
 		tsxFile, err := os.Open(tsxPath)
 		if err != nil {
-			log.Printf("Warning: failed to open tileset %s: %v", tsxPath, err)
+			warnings = append(warnings, assetError(AssetTileset, tsxPath, false, err))
 			continue
 		}
-		defer tsxFile.Close()
 
 		var tsx struct {
 			XMLName    xml.Name `xml:"tileset"`
@@ -193,17 +304,36 @@ func loadTiledLevel(path string) (*Level, error) {
 				Width  int    `xml:"width,attr"`
 				Height int    `xml:"height,attr"`
 			} `xml:"image"`
+			Tiles []struct {
+				ID         int           `xml:"id,attr"`
+				Properties []tsxProperty `xml:"properties>property"`
+			} `xml:"tile"`
 		}
 
-		if err := xml.NewDecoder(tsxFile).Decode(&tsx); err != nil {
-			log.Printf("Warning: failed to parse tileset %s: %v", tsxPath, err)
+		err = xml.NewDecoder(tsxFile).Decode(&tsx)
+		tsxFile.Close()
+		if err != nil {
+			warnings = append(warnings, assetError(AssetTileset, tsxPath, false, fmt.Errorf("%w: %v", ErrInvalidTileset, err)))
+			continue
+		}
+		if tsx.TileWidth <= 0 || tsx.TileHeight <= 0 {
+			warnings = append(warnings, assetError(AssetTileset, tsxPath, false,
+				fmt.Errorf("%w: tile size %dx%d", ErrInvalidTileset, tsx.TileWidth, tsx.TileHeight)))
 			continue
 		}
 
+		// Свойства тайлов не зависят от картинки
+		for _, tile := range tsx.Tiles {
+			for _, p := range tile.Properties {
+				props[tileset.FirstGID+tile.ID] = append(props[tileset.FirstGID+tile.ID], p.property())
+			}
+		}
+
 		imgPath := filepath.Join(filepath.Dir(tsxPath), tsx.Image.Source)
+		files = append(files, imgPath)
 		tilesetImg, _, err := ebitenutil.NewImageFromFile(imgPath)
 		if err != nil {
-			log.Printf("Warning: failed to load tileset image %s: %v", imgPath, err)
+			warnings = append(warnings, assetError(AssetImage, imgPath, false, err))
 			continue
 		}
 
@@ -223,69 +353,361 @@ func loadTiledLevel(path string) (*Level, error) {
 					sy+tsx.TileHeight,
 				)).(*ebiten.Image)
 
-				tileImages[gid] = tile
+				images[gid] = tile
 			}
 		}
 	}
+	return images, tileTerrains(props), files, warnings
+}
+
+// loadTiledLevel загружает уровень из Tiled JSON. Ошибка всегда *AssetError
+// с видом AssetMap; проблемы тайлсетов не мешают загрузке и попадают в
+// Level.Warnings.
+func loadTiledLevel(path string) (*Level, error) {
+	absPath, _ := filepath.Abs(path)
+	log.Printf("Loading level from: %s", absPath)
+
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, assetError(AssetMap, path, false, err)
+	}
+
+	var tiledMap TiledMap
+	if err := json.Unmarshal(file, &tiledMap); err != nil {
+		return nil, assetError(AssetMap, path, false, fmt.Errorf("%w: %v", ErrInvalidMap, err))
+	}
+
+	// У бесконечной карты размер задают куски слоев
+	normalizeInfinite(&tiledMap)
+
+	// Проверка обязательных полей
+	if tiledMap.Width == 0 || tiledMap.Height == 0 {
+		return nil, assetError(AssetMap, path, false, fmt.Errorf("%w: dimensions %dx%d", ErrInvalidMap, tiledMap.Width, tiledMap.Height))
+	}
+	// Размер тайла - делитель при переводе объектов в клетки
+	if tiledMap.TileWidth <= 0 || tiledMap.TileHeight <= 0 {
+		return nil, assetError(AssetMap, path, false, fmt.Errorf("%w: tile size %dx%d", ErrInvalidMap, tiledMap.TileWidth, tiledMap.TileHeight))
+	}
+
+	tileImages, terrain, files, warnings := loadTilesetImages(&tiledMap, path)
+	layerImages, layerFiles, layerWarnings := loadLayerImages(&tiledMap, path)
+	files = append(files, layerFiles...)
+	warnings = append(warnings, layerWarnings...)
+	for _, w := range warnings {
+		log.Printf("Warning: %v", w)
+	}
 
 	// Остальной код парсинга объектов...
 	var enemies []Enemy
-	var startPos Position
-
-	for _, layer := range tiledMap.Layers {
+	var startPos, exitPos Position
+	var doors []Door
+	var keys []Key
+	var triggers []Trigger
+	var colliders []Shape
+	var lights []Light
+
+	for _, layer := range tiledMap.AllLayers() {
 		if layer.Type == "objectgroup" {
 			for _, obj := range layer.Objects {
+				if collides, _ := propertyBool(obj.Properties, "collides"); (collides || obj.Type == "collision") && !obj.Point {
+					colliders = append(colliders, objectShape(obj))
+				}
+
 				switch obj.Type {
 				case "enemy":
-					enemies = append(enemies, Enemy{
-						Type:   obj.Name,
-						Health: 30,
-						Position: Position{
-							X: obj.X,
-							Y: obj.Y,
-						},
-						Speed:  1.5,
-						Damage: 10,
-					})
+					enemy := NewEnemy(obj.Name, Position{X: obj.X, Y: obj.Y})
+					enemy.applyProperties(obj.Properties)
+					enemy.ObjectID = obj.Id
+					if ref, ok := propertyValue(obj.Properties, "patrol"); ok {
+						route, found := findPatrolRoute(&tiledMap, ref)
+						if !found {
+							log.Printf("Warning: enemy #%d: patrol route %v not found", obj.Id, ref)
+						}
+						enemy.Patrol = route
+					}
+					enemies = append(enemies, enemy)
 				case "player_start":
 					startPos = Position{X: obj.X, Y: obj.Y}
+				case "level_exit":
+					exitPos = Position{X: obj.X, Y: obj.Y}
+					triggers = append(triggers, newObjectTrigger(obj))
+				case "door":
+					keyID, _ := propertyFloat(obj.Properties, "key_id")
+					doors = append(doors, Door{
+						Tile:     TilePoint{X: int(obj.X) / tiledMap.TileWidth, Y: int(obj.Y) / tiledMap.TileHeight},
+						KeyID:    int(keyID),
+						ObjectID: obj.Id,
+					})
+				case "key":
+					keyID, _ := propertyFloat(obj.Properties, "key_id")
+					keys = append(keys, Key{ID: int(keyID), Position: Position{X: obj.X, Y: obj.Y}, ObjectID: obj.Id})
+				case "torch", "light":
+					lights = append(lights, newObjectLight(obj))
+				default:
+					if isTriggerType(obj.Type) {
+						triggers = append(triggers, newObjectTrigger(obj))
+					}
 				}
 			}
 		}
 	}
 
-	return &Level{
+	level := &Level{
 		Name:          filepath.Base(path),
+		Source:        path,
+		Files:         append([]string{path}, files...),
+		Warnings:      warnings,
 		TiledMap:      &tiledMap,
 		TileImages:    tileImages,
+		TileTerrain:   terrain,
+		LayerImages:   layerImages,
 		Enemies:       enemies,
 		StartPosition: startPos,
+		ExitPosition:  exitPos,
+		Doors:         doors,
+		Keys:          keys,
+		Triggers:      triggers,
+		Colliders:     colliders,
 		Width:         tiledMap.Width,
 		Height:        tiledMap.Height,
-	}, nil
+		Streaming:     tiledMap.Infinite,
+		Lights:        lights,
+	}
+	level.Dark, _ = propertyBool(tiledMap.Properties, "dark")
+	level.prepare()
+
+	return level, nil
 }
 
-// Старая функция для создания уровня, если не удалось загрузить из Tiled
-func createForestLevel() Level {
-	width, height := WinWidth/tileSize, WinHeight/tileSize
+// findPatrolRoute ищет линию или многоугольник маршрута по ссылке из
+// свойства "patrol": id объекта (тип свойства object) или имя объекта
+func findPatrolRoute(m *TiledMap, ref interface{}) (PatrolRoute, bool) {
+	for _, layer := range m.AllLayers() {
+		for _, obj := range layer.Objects {
+			match := false
+			switch v := ref.(type) {
+			case float64:
+				match = int(v) == obj.Id
+			case string:
+				match = v == obj.Name
+			}
+			if !match || (len(obj.Polyline) < 2 && len(obj.Polygon) < 2) {
+				continue
+			}
+			shape := objectShape(obj)
+			return PatrolRoute{ObjectID: obj.Id, Points: shape.Points, Loop: shape.Kind == ShapePolygon}, true
+		}
+	}
+	return PatrolRoute{}, false
+}
+
+// createForestLevel создает процедурный лесной уровень, если не удалось загрузить из Tiled
+func createForestLevel(rng *RandStream) Level {
+	return GenerateForest(DefaultForestParams(int64(rng.Uint64())))
+}
 
-	l := Level{
-		Name:          "Forest Level",
-		StartPosition: Position{X: 100, Y: 100},
-		Width:         width,
-		Height:        height,
+// createDungeonLevel создает процедурное подземелье
+func createDungeonLevel(rng *RandStream) Level {
+	return GenerateDungeon(DefaultDungeonParams(int64(rng.Uint64())))
+}
+
+// PixelSize возвращает размер уровня в пикселях
+func (l *Level) PixelSize() (float64, float64) {
+	tw, th := l.TileSize()
+	return float64(l.Width * tw), float64(l.Height * th)
+}
+
+// TileSize возвращает размер клетки уровня в пикселях
+func (l *Level) TileSize() (int, int) {
+	if l.TiledMap != nil {
+		return l.TiledMap.TileWidth, l.TiledMap.TileHeight
+	}
+	return tileSize, tileSize
+}
+
+// prepare строит навигацию и пространственный индекс уровня
+func (l *Level) prepare() {
+	// Первый prepare видит уровень таким, каким его задал автор
+	if l.authored == nil {
+		authored := l.clone()
+		l.authored = &authored
+	}
+	l.Nav = NewPathfinder(NewNavGrid(l), true)
+	for _, d := range l.Doors {
+		if !d.Open {
+			l.Nav.Grid().SetCost(d.Tile.X, d.Tile.Y, 0)
+		}
+	}
+	for i := range l.Enemies {
+		l.Enemies[i].body = NewBody(l.Enemies[i].Speed)
 	}
+	l.RebuildSpatial()
+	l.buildTriggers()
+	l.markColliders()
+}
+
+// clone копирует изменяемые данные уровня. Навигация, индексы и кэш
+// тайлов не копируются: их строят prepare и tileCache.
+func (l *Level) clone() Level {
+	c := *l
+	c.Map = make([][]TileType, len(l.Map))
+	for y, row := range l.Map {
+		c.Map[y] = append([]TileType(nil), row...)
+	}
+	if l.TiledMap != nil {
+		m := *l.TiledMap
+		m.Layers = cloneLayers(l.TiledMap.Layers)
+		c.TiledMap = &m
+	}
+	c.Enemies = append([]Enemy(nil), l.Enemies...)
+	for i := range c.Enemies {
+		c.Enemies[i].path = nil
+	}
+	c.Doors = append([]Door(nil), l.Doors...)
+	c.Keys = append([]Key(nil), l.Keys...)
+	c.Pickups = append([]Pickup(nil), l.Pickups...)
+	c.Triggers = append([]Trigger(nil), l.Triggers...)
+	c.Lights = append([]Light(nil), l.Lights...)
+	c.Nav, c.Spatial, c.tiles = nil, nil, nil
+	c.triggers, c.colliderTiles = nil, nil
+	c.authored, c.terrainLayers = nil, nil
+	return c
+}
+
+// resetToAuthored возвращает уровню авторский вид: убитые враги на своих
+// местах, ключи на земле, двери заперты. Прогресс на уровне теряется.
+func (l *Level) resetToAuthored() {
+	authored := l.authored
+	if authored == nil {
+		return
+	}
+	l.InvalidateTiles()
+	*l = authored.clone()
+	l.authored = authored
+	l.prepare()
+}
 
-	// Добавляем несколько врагов
-	l.Enemies = append(l.Enemies, Enemy{
-		Type:   "goblin",
-		Health: 30,
-		Position: Position{
-			X: float64((rand.Intn(width-2) + 1) * tileSize),
-			Y: float64((rand.Intn(height-2) + 1) * tileSize),
-		},
-		Speed:  1.5,
-		Damage: 10,
-	})
-	return l
+// keepAsAuthored делает текущий вид уровня авторским (после редактора)
+func (l *Level) keepAsAuthored() {
+	authored := l.clone()
+	l.authored = &authored
+}
+
+// markColliders закрывает для поиска пути клетки, которые задевают
+// Colliders. Сами коллизии игрока проверяются по точной форме в Blocked.
+func (l *Level) markColliders() {
+	l.colliderTiles = make(map[TilePoint]bool)
+	grid := l.Nav.Grid()
+	for _, c := range l.Colliders {
+		min := grid.ToTile(Position{X: float64(c.Bounds.Min.X), Y: float64(c.Bounds.Min.Y)})
+		max := grid.ToTile(Position{X: float64(c.Bounds.Max.X - 1), Y: float64(c.Bounds.Max.Y - 1)})
+		for y := min.Y; y <= max.Y; y++ {
+			for x := min.X; x <= max.X; x++ {
+				cell := image.Rect(x*grid.TileWidth, y*grid.TileHeight, (x+1)*grid.TileWidth, (y+1)*grid.TileHeight)
+				if !grid.inBounds(x, y) || !grid.Walkable(x, y) || !c.Overlaps(cell) {
+					continue
+				}
+				grid.SetCost(x, y, 0)
+				l.colliderTiles[TilePoint{X: x, Y: y}] = true
+			}
+		}
+	}
+}
+
+// OpenDoor открывает дверь i и делает ее клетку проходимой
+func (l *Level) OpenDoor(i int) {
+	d := &l.Doors[i]
+	if d.Open {
+		return
+	}
+	d.Open = true
+	l.Nav.Grid().SetCost(d.Tile.X, d.Tile.Y, 1)
+	l.Nav.Invalidate()
+}
+
+// Blocked сообщает, задевает ли прямоугольник непроходимую клетку
+func (l *Level) Blocked(r image.Rectangle) bool {
+	if l.Nav == nil {
+		return false
+	}
+	grid := l.Nav.Grid()
+	min := grid.ToTile(Position{X: float64(r.Min.X), Y: float64(r.Min.Y)})
+	max := grid.ToTile(Position{X: float64(r.Max.X - 1), Y: float64(r.Max.Y - 1)})
+	for y := min.Y; y <= max.Y; y++ {
+		for x := min.X; x <= max.X; x++ {
+			if grid.inBounds(x, y) && !grid.Walkable(x, y) && !l.colliderTiles[TilePoint{X: x, Y: y}] {
+				return true
+			}
+		}
+	}
+	for i := range l.Colliders {
+		if l.Colliders[i].Overlaps(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// RebuildSpatial заново индексирует всех врагов уровня
+func (l *Level) RebuildSpatial() {
+	l.Spatial = NewSpatialHash(SpatialCellSize)
+	for i := range l.Enemies {
+		l.Spatial.Insert(i, l.Enemies[i].GetCollisionRect())
+	}
+}
+
+// HazardAt возвращает источник урона от местности в точке pos.
+// В Tiled урон задается свойством "damage" тайла или слоя (см. TerrainAt).
+func (l *Level) HazardAt(pos Position) (DamageSource, bool) {
+	t := l.TerrainAt(pos)
+	if t.Damage <= 0 {
+		return DamageSource{}, false
+	}
+	// Кулдаун общий для местности одного типа урона, иначе шаг на соседнюю
+	// клетку лавы бил бы сразу, мимо HazardDamageInterval
+	return DamageSource{
+		Kind:                  SourceHazard,
+		ID:                    int(t.DamageType),
+		Amount:                t.Damage,
+		Type:                  t.DamageType,
+		Cooldown:              HazardDamageInterval,
+		IgnoreInvulnerability: true,
+	}, true
+}
+
+// propertyValue ищет свойство Tiled по имени
+func propertyValue(props []Property, name string) (interface{}, bool) {
+	for _, prop := range props {
+		if prop.Name == name {
+			return prop.Value, true
+		}
+	}
+	return nil, false
+}
+
+func propertyFloat(props []Property, name string) (float64, bool) {
+	v, ok := propertyValue(props, name)
+	if !ok {
+		return 0, false
+	}
+	f, ok := v.(float64) // JSON декодирует все числа в float64
+	return f, ok
+}
+
+func propertyString(props []Property, name string) (string, bool) {
+	v, ok := propertyValue(props, name)
+	if !ok {
+		return "", false
+	}
+	s, ok := v.(string)
+	return s, ok
+}
+
+func propertyBool(props []Property, name string) (bool, bool) {
+	v, ok := propertyValue(props, name)
+	if !ok {
+		return false, false
+	}
+	b, ok := v.(bool)
+	return b, ok
 }
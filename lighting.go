@@ -0,0 +1,283 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Light - точечный источник света в координатах уровня
+type Light struct {
+	Position Position
+	Radius   float64
+	Color    color.RGBA
+	Flicker  bool // Пламя: радиус и яркость слегка дрожат
+}
+
+// newObjectLight создает свет из объекта Tiled "torch" или "light".
+// Свойства: "radius", "color" (#RRGGBB) и "flicker" (у факелов по умолчанию).
+func newObjectLight(obj Object) Light {
+	l := Light{
+		Position: Position{X: obj.X + obj.Width/2, Y: obj.Y + obj.Height/2},
+		Radius:   TorchLightRadius,
+		Color:    torchLightColor,
+		Flicker:  obj.Type == "torch",
+	}
+	if obj.GID != 0 {
+		l.Position.Y -= obj.Height // У тайловых объектов Y - нижний край
+	}
+	if v, ok := propertyFloat(obj.Properties, "radius"); ok {
+		l.Radius = v
+	}
+	if v, ok := propertyString(obj.Properties, "color"); ok {
+		if c, ok := parseTintColor(v); ok {
+			l.Color = color.RGBA{uint8(c[0] * 255), uint8(c[1] * 255), uint8(c[2] * 255), 255}
+		}
+	}
+	if v, ok := propertyBool(obj.Properties, "flicker"); ok {
+		l.Flicker = v
+	}
+	return l
+}
+
+var (
+	torchLightColor  = color.RGBA{255, 190, 110, 255}
+	playerLightColor = color.RGBA{255, 235, 200, 255}
+)
+
+// DayCycle - время суток по игровым часам. Сутки длятся DayLength,
+// игра начинается в DayStartHour.
+type DayCycle struct {
+	start time.Time
+}
+
+func NewDayCycle() DayCycle {
+	return DayCycle{start: gameClock.Now().Add(-DayLength * DayStartHour / 24)}
+}
+
+// Phase возвращает долю суток: 0 - полночь, 0.5 - полдень
+func (d DayCycle) Phase() float64 {
+	t := gameClock.Since(d.start) % DayLength
+	return float64(t) / float64(DayLength)
+}
+
+// Clock возвращает время суток в часах и минутах
+func (d DayCycle) Clock() (int, int) {
+	minutes := int(d.Phase() * 24 * 60)
+	return minutes / 60, minutes % 60
+}
+
+// ambientKey - цвет общего освещения в момент суток
+type ambientKey struct {
+	phase float64
+	color [3]float64
+}
+
+// Ключевые точки суток, между ними цвет плавно меняется
+var ambientKeys = []ambientKey{
+	{0, [3]float64{0.12, 0.14, 0.28}},    // Полночь
+	{0.22, [3]float64{0.14, 0.16, 0.30}}, // Перед рассветом
+	{0.28, [3]float64{0.85, 0.60, 0.45}}, // Рассвет
+	{0.35, [3]float64{1, 1, 1}},          // День
+	{0.72, [3]float64{1, 1, 1}},
+	{0.78, [3]float64{0.90, 0.55, 0.40}}, // Закат
+	{0.85, [3]float64{0.14, 0.16, 0.30}}, // Ночь
+	{1, [3]float64{0.12, 0.14, 0.28}},
+}
+
+// Ambient возвращает множители RGB общего освещения
+func (d DayCycle) Ambient() [3]float64 {
+	p := d.Phase()
+	for i := 1; i < len(ambientKeys); i++ {
+		a, b := ambientKeys[i-1], ambientKeys[i]
+		if p > b.phase {
+			continue
+		}
+		t := (p - a.phase) / (b.phase - a.phase)
+		var c [3]float64
+		for j := range c {
+			c[j] = a.color[j] + (b.color[j]-a.color[j])*t
+		}
+		return c
+	}
+	return ambientKeys[0].color
+}
+
+// Night сообщает, что сейчас ночь: общего света меньше NightThreshold
+func (d DayCycle) Night() bool {
+	c := d.Ambient()
+	return (c[0]+c[1]+c[2])/3 < NightThreshold
+}
+
+// Night сообщает, что на уровне ночь. В подземельях (Level.Dark) ночь всегда.
+func (g *Game) Night(level *Level) bool {
+	return level.Dark || g.dayCycle.Night()
+}
+
+// ambientLight - общий свет уровня: время суток или темнота подземелья
+func (g *Game) ambientLight(level *Level) [3]float64 {
+	if level.Dark {
+		return ambientKeys[0].color
+	}
+	return g.dayCycle.Ambient()
+}
+
+// Lit сообщает, что точка освещена источником уровня (свет игрока не в счет)
+func (l *Level) Lit(pos Position) bool {
+	for _, light := range l.Lights {
+		if distance(pos, light.Position) < light.Radius*LitRadiusFraction {
+			return true
+		}
+	}
+	return false
+}
+
+// Occludes сообщает, что клетка загораживает свет: стены, деревья,
+// закрытые двери и коллизии Tiled. Вода свет пропускает.
+func (l *Level) Occludes(x, y int) bool {
+	if l.TiledMap == nil && y >= 0 && y < len(l.Map) && x >= 0 && x < len(l.Map[y]) && l.Map[y][x] == TileWater {
+		return false
+	}
+	return l.Nav != nil && !l.Nav.grid.Walkable(x, y)
+}
+
+// lightFan возвращает точки контура освещенной области: лучи из центра
+// света до первой загораживающей клетки или до радиуса
+func (l *Level) lightFan(light Light, radius float64) []Position {
+	tw, th := l.TileSize()
+	step := float64(min(tw, th)) / 4
+	// Факел на стене не гасит сам себя: его клетку пропускаем
+	own := TilePoint{X: floorDiv(int(light.Position.X), tw), Y: floorDiv(int(light.Position.Y), th)}
+	points := make([]Position, LightRays)
+	for i := range points {
+		sin, cos := math.Sincos(2 * math.Pi * float64(i) / LightRays)
+		d := step
+		for ; d < radius; d += step {
+			cell := TilePoint{
+				X: floorDiv(int(light.Position.X+cos*d), tw),
+				Y: floorDiv(int(light.Position.Y+sin*d), th),
+			}
+			if cell != own && l.Occludes(cell.X, cell.Y) {
+				// Свет заходит немного в стену, чтобы освещать ее край
+				d += LightWallPenetration
+				break
+			}
+		}
+		d = math.Min(d, radius)
+		points[i] = Position{X: light.Position.X + cos*d, Y: light.Position.Y + sin*d}
+	}
+	return points
+}
+
+// lightGradient - белое пятно с плавным затуханием к краю
+var lightGradient *ebiten.Image
+
+func lightGradientImage() *ebiten.Image {
+	if lightGradient != nil {
+		return lightGradient
+	}
+	const size = 128
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx := (float64(x) + 0.5 - size/2) / (size / 2)
+			dy := (float64(y) + 0.5 - size/2) / (size / 2)
+			f := math.Max(0, 1-math.Hypot(dx, dy))
+			v := uint8(f * f * 255)
+			img.SetRGBA(x, y, color.RGBA{v, v, v, v})
+		}
+	}
+	lightGradient = ebiten.NewImageFromImage(img)
+	return lightGradient
+}
+
+// Умножение кадра на карту освещения
+var blendMultiply = ebiten.Blend{
+	BlendFactorSourceRGB:        ebiten.BlendFactorDestinationColor,
+	BlendFactorSourceAlpha:      ebiten.BlendFactorZero,
+	BlendFactorDestinationRGB:   ebiten.BlendFactorZero,
+	BlendFactorDestinationAlpha: ebiten.BlendFactorOne,
+	BlendOperationRGB:           ebiten.BlendOperationAdd,
+	BlendOperationAlpha:         ebiten.BlendOperationAdd,
+}
+
+// drawLighting затемняет кадр по времени суток и добавляет свет факелов
+// и игрока. Днем, когда общий свет полный, ничего не рисует.
+func (g *Game) drawLighting(screen *ebiten.Image, level *Level) {
+	ambient := g.ambientLight(level)
+	if ambient == [3]float64{1, 1, 1} {
+		return
+	}
+
+	w, h := screen.Bounds().Dx(), screen.Bounds().Dy()
+	if g.lightMap == nil || g.lightMap.Bounds().Dx() != w || g.lightMap.Bounds().Dy() != h {
+		if g.lightMap != nil {
+			g.lightMap.Deallocate()
+		}
+		g.lightMap = ebiten.NewImage(w, h)
+	}
+	g.lightMap.Fill(color.RGBA{uint8(ambient[0] * 255), uint8(ambient[1] * 255), uint8(ambient[2] * 255), 255})
+
+	view := image.Rect(int(g.camera.X), int(g.camera.Y), int(g.camera.X+g.camera.Width), int(g.camera.Y+g.camera.Height))
+	for _, light := range level.Lights {
+		g.drawLight(level, light, view)
+	}
+	if g.player != nil {
+		g.drawLight(level, Light{Position: g.player.Center(), Radius: PlayerLightRadius, Color: playerLightColor}, view)
+	}
+
+	op := &ebiten.DrawImageOptions{}
+	op.Blend = blendMultiply
+	screen.DrawImage(g.lightMap, op)
+}
+
+// drawLight добавляет на карту освещения один источник, если он виден
+func (g *Game) drawLight(level *Level, light Light, view image.Rectangle) {
+	radius, intensity := light.Radius, 1.0
+	if light.Flicker {
+		// Несколько синусов разной частоты похожи на пламя
+		t := float64(gameClock.Now().UnixMilli()) / 1000
+		seed := light.Position.X*0.37 + light.Position.Y*0.11
+		f := math.Sin(t*7+seed)*0.5 + math.Sin(t*13.3+seed*2)*0.3 + math.Sin(t*2.1+seed)*0.2
+		radius *= 1 + f*LightFlicker
+		intensity *= 1 + f*LightFlicker
+	}
+	bounds := image.Rect(int(light.Position.X-radius), int(light.Position.Y-radius),
+		int(light.Position.X+radius)+1, int(light.Position.Y+radius)+1)
+	if !bounds.Overlaps(view) {
+		return
+	}
+
+	fan := level.lightFan(light, radius)
+	tex := lightGradientImage()
+	half := float64(tex.Bounds().Dx()) / 2
+	r := float32(float64(light.Color.R) / 255 * intensity)
+	gr := float32(float64(light.Color.G) / 255 * intensity)
+	b := float32(float64(light.Color.B) / 255 * intensity)
+
+	vertex := func(p Position) ebiten.Vertex {
+		s := g.camera.ToScreen(p)
+		return ebiten.Vertex{
+			DstX: float32(s.X), DstY: float32(s.Y),
+			SrcX:   float32(half + (p.X-light.Position.X)/radius*half),
+			SrcY:   float32(half + (p.Y-light.Position.Y)/radius*half),
+			ColorR: r, ColorG: gr, ColorB: b, ColorA: 1,
+		}
+	}
+	vertices := make([]ebiten.Vertex, 0, len(fan)+1)
+	vertices = append(vertices, vertex(light.Position))
+	for _, p := range fan {
+		vertices = append(vertices, vertex(p))
+	}
+	indices := make([]uint16, 0, len(fan)*3)
+	for i := range fan {
+		indices = append(indices, 0, uint16(i+1), uint16((i+1)%len(fan)+1))
+	}
+
+	op := &ebiten.DrawTrianglesOptions{}
+	op.Blend = ebiten.BlendLighter
+	g.lightMap.DrawTriangles(vertices, indices, tex, op)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// dayAt возвращает сутки, в которых сейчас hour часов
+func dayAt(hour float64) DayCycle {
+	return DayCycle{start: gameClock.Now().Add(-time.Duration(hour / 24 * float64(DayLength)))}
+}
+
+func sameColor(a, b [3]float64) bool {
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-6 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDayCycleAmbient(t *testing.T) {
+	white := [3]float64{1, 1, 1}
+	sunset := ambientKeys[5].color
+	tests := []struct {
+		name  string
+		hour  float64
+		want  [3]float64
+		night bool
+	}{
+		{"midnight", 0, ambientKeys[0].color, true},
+		{"before dawn", 0.22 * 24, ambientKeys[1].color, true},
+		{"dawn", 0.28 * 24, ambientKeys[2].color, false},
+		{"noon", 12, white, false},
+		{"halfway to sunset", 0.75 * 24, [3]float64{(1 + sunset[0]) / 2, (1 + sunset[1]) / 2, (1 + sunset[2]) / 2}, false},
+		{"night", 0.85 * 24, ambientKeys[6].color, true},
+		{"next day wraps around", 24 + 12, white, false},
+	}
+	gameClock.SetManual()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := dayAt(tt.hour)
+			if got := d.Ambient(); !sameColor(got, tt.want) {
+				t.Errorf("Ambient() = %v, want %v", got, tt.want)
+			}
+			if got := d.Night(); got != tt.night {
+				t.Errorf("Night() = %v, want %v", got, tt.night)
+			}
+		})
+	}
+}
+
+func TestDayCycleContinuous(t *testing.T) {
+	gameClock.SetManual()
+	d := NewDayCycle()
+	if h, m := d.Clock(); h != DayStartHour || m != 0 {
+		t.Errorf("new day starts at %02d:%02d, want %02d:00", h, m, DayStartHour)
+	}
+
+	// Свет меняется плавно, в том числе при переходе через полночь
+	const steps = 1000
+	prev := d.Ambient()
+	nights := 0
+	for i := 0; i < steps; i++ {
+		gameClock.Advance(DayLength / steps)
+		c := d.Ambient()
+		for j := range c {
+			if math.Abs(c[j]-prev[j]) > 0.1 {
+				t.Fatalf("step %d at %.3f of the day: ambient jumped from %v to %v", i, d.Phase(), prev, c)
+			}
+		}
+		if d.Night() {
+			nights++
+		}
+		prev = c
+	}
+	if nights == 0 || nights == steps {
+		t.Errorf("night for %d of %d steps, want both day and night", nights, steps)
+	}
+}
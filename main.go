@@ -1,41 +1,166 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
 func main() {
+	// Замер отрисовки тайлов открывает свое окно
+	if len(os.Args) > 2 && os.Args[1] == "bench" && os.Args[2] == "tiles" {
+		runTileBenchmark()
+		return
+	}
+
+	// Служебные подкоманды, не требующие окна
+	if len(os.Args) > 1 && os.Args[1] == "generate" {
+		os.Exit(runGenerate(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(runValidate(os.Args[2:], os.Stdout))
+	}
+
+	cfg, err := parseFlags(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
 	// Инициализация игровых ресурсов
-	if err := loadGameResources(); err != nil {
-		log.Fatalf("Failed to load game resources: %v", err)
+	problems := loadGameResources()
+
+	log.Printf("Random seed: %d", cfg.Seed)
+
+	// Создание игры
+	game := NewGame(cfg)
+	problems = append(problems, game.startupErrors...)
+
+	// Без окна некому показать экран ошибок, поэтому скрипт должен
+	// узнать о них по коду выхода, как в строгом режиме
+	if required := requiredErrors(problems); (cfg.Strict || cfg.Headless()) && len(required) > 0 {
+		fmt.Fprintf(os.Stderr, "Missing %d required asset(s), refusing to start:\n", len(required))
+		for _, err := range required {
+			fmt.Fprintf(os.Stderr, "  %v\n", err)
+		}
+		os.Exit(1)
+	}
+	if len(problems) > 0 && !cfg.Headless() {
+		game.scenes.Push(NewStartupErrorScene(problems))
 	}
 
-	// Настройка окна игры
-	configureWindow()
+	// Прогон без окна для скриптов и CI
+	if cfg.Headless() {
+		os.Exit(runHeadless(game, cfg.Ticks, cfg.TPS))
+	}
 
-	// Создание и запуск игры
-	game := NewGame()
+	// Настройка окна игры и запуск
+	configureWindow(cfg)
 	if err := ebiten.RunGame(game); err != nil {
 		log.Fatal(err)
 	}
 }
 
-func loadGameResources() error {
+// runHeadless выполняет ticks обновлений без окна и возвращает код выхода:
+// 0 - успех, 1 - ошибка в Update или смерть игрока (об ошибках загрузки
+// сообщает main). Игровые часы идут вручную, на 1/tps секунды за тик, как
+// в игре с окном.
+func runHeadless(g *Game, ticks, tps int) int {
+	gameClock.SetManual()
+	for i := 0; i < ticks; i++ {
+		gameClock.Advance(time.Second / time.Duration(tps))
+		if err := g.Update(); err != nil {
+			log.Printf("Tick %d failed: %v", i, err)
+			return 1
+		}
+		if g.player.health <= 0 {
+			log.Printf("Player died on tick %d at (%.1f, %.1f), level %d", i, g.player.x, g.player.y, g.currentLevel)
+			log.Printf("RNG state: %+v", g.rng.State())
+			return 1
+		}
+	}
+
+	log.Printf("Ran %d ticks: level %d, player at (%.1f, %.1f), health %d/%d",
+		ticks, g.currentLevel, g.player.x, g.player.y, g.player.health, g.player.maxHealth)
+	log.Printf("RNG state: %+v", g.rng.State())
+	return 0
+}
+
+// runGenerate генерирует лесной уровень и сохраняет его как карту Tiled
+func runGenerate(args []string) int {
+	forest := DefaultForestParams(0)
+	dungeon := DefaultDungeonParams(0)
+	kind := "forest"
+	out := "generated.json"
+
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	fs.StringVar(&kind, "kind", kind, "level kind: forest or dungeon")
+	fs.Int64Var(&forest.Seed, "seed", 0, "random seed (0 picks one from the clock)")
+	fs.IntVar(&forest.Width, "width", forest.Width, "map width in tiles")
+	fs.IntVar(&forest.Height, "height", forest.Height, "map height in tiles")
+	fs.Float64Var(&forest.EnemyDensity, "density", forest.EnemyDensity, "forest: enemy chance per reachable tile")
+	fs.IntVar(&dungeon.EnemiesPerRoom, "room-enemies", dungeon.EnemiesPerRoom, "dungeon: maximum enemies per room")
+	fs.StringVar(&out, "out", out, "output Tiled JSON file")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if forest.Seed == 0 {
+		forest.Seed = time.Now().UnixNano()
+	}
+
+	var level Level
+	switch kind {
+	case "forest":
+		if forest.Width < 4 || forest.Height < 4 {
+			fmt.Fprintf(os.Stderr, "map must be at least 4x4 tiles, got %dx%d\n", forest.Width, forest.Height)
+			return 2
+		}
+		level = GenerateForest(forest)
+	case "dungeon":
+		dungeon.Seed, dungeon.Width, dungeon.Height = forest.Seed, forest.Width, forest.Height
+		if min(dungeon.Width, dungeon.Height) < dungeon.MinRoomSize+4 {
+			fmt.Fprintf(os.Stderr, "dungeon must be at least %dx%d tiles\n", dungeon.MinRoomSize+4, dungeon.MinRoomSize+4)
+			return 2
+		}
+		level = GenerateDungeon(dungeon)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown level kind %q\n", kind)
+		return 2
+	}
+
+	if err := saveTiledMap(tiledMapFromLevel(&level), out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	log.Printf("Generated %dx%d %s with %d enemies (seed %d) to %s",
+		level.Width, level.Height, kind, len(level.Enemies), forest.Seed, out)
+	return 0
+}
+
+// loadGameResources загружает общие ресурсы и возвращает ошибки всех,
+// что не загрузились. Игра может идти и с ними: вместо них заглушки.
+func loadGameResources() []error {
 	// Загрузка спрайтов персонажа
-	LoadSprites()
+	errs := LoadSprites()
 
 	// Загрузка UI элементов (сердечки и т.д.)
-	LoadUIResources()
+	errs = append(errs, LoadUIResources()...)
 
-	// Здесь можно добавить загрузку других ресурсов
-	// Например: LoadSounds(), LoadLevels() и т.д.
-
-	return nil
+	return errs
 }
 
-func configureWindow() {
+func configureWindow(cfg *Config) {
 	// Устанавливаем начальный размер окна (половина от полного разрешения)
 	ebiten.SetWindowSize(WinWidth/2, WinHeight/2)
 
@@ -44,6 +169,12 @@ func configureWindow() {
 	ebiten.SetWindowResizable(true)
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
 
+	// Update продолжает вызываться без фокуса, чтобы игра могла встать на паузу
+	ebiten.SetRunnableOnUnfocused(true)
+
+	ebiten.SetFullscreen(cfg.Fullscreen)
+	ebiten.SetTPS(cfg.TPS)
+
 	// Опционально: можно установить иконку окна
 	// if icon, err := loadWindowIcon(); err == nil {
 	//     ebiten.SetWindowIcon([]image.Image{icon})
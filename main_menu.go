@@ -15,7 +15,7 @@ type MainMenu struct {
 	options    []string
 	selected   int
 	fontFace   font.Face
-	background *ebiten.Image
+	background color.Color
 	title      string
 	version    string
 }
@@ -33,9 +33,8 @@ func NewMainMenu() *MainMenu {
 		version:  "v1.0.0",
 	}
 
-	// Создаем фоновое изображение
-	mm.background = ebiten.NewImage(WinWidth, WinHeight)
-	mm.background.Fill(color.RGBA{30, 30, 60, 255})
+	// Цвет фона
+	mm.background = color.RGBA{30, 30, 60, 255}
 
 	return mm
 }
@@ -55,16 +54,13 @@ func (mm *MainMenu) Update(g *Game) error {
 func (mm *MainMenu) handleSelection(g *Game) {
 	switch mm.options[mm.selected] {
 	case "Start Game":
-		g.gameState = StatePlaying
-		g.currentLevel = 0
-		g.player = NewPlayer()
-		g.levels = CreateLevels()
+		g.RestartGame()
 	// case "Load Game":
 	// 	if err := g.LoadGame("save.dat"); err != nil {
 	// 		log.Println("Failed to load game:", err)
 	// 	}
 	// case "Options":
-	// 	g.gameState = StateOptions
+	// 	g.scenes.Push(NewOptionsMenu())
 	case "Quit":
 		g.quitGame()
 	}
@@ -74,10 +70,16 @@ func (g *Game) quitGame() {
 	ebiten.Termination = errors.New("game quit from menu")
 }
 
-func (mm *MainMenu) Draw(screen *ebiten.Image) {
+func (mm *MainMenu) Enter(g *Game) {
+	mm.selected = 0
+}
+
+func (mm *MainMenu) Exit(g *Game) {}
+
+func (mm *MainMenu) Draw(screen *ebiten.Image, g *Game) {
 	// Рисуем фон
-	op := &ebiten.DrawImageOptions{}
-	screen.DrawImage(mm.background, op)
+	screen.Fill(mm.background)
+	w, h := g.display.Width(), g.display.Height()
 
 	// Создаем color.Color из color.RGBA
 	white := color.White
@@ -86,7 +88,7 @@ func (mm *MainMenu) Draw(screen *ebiten.Image) {
 
 	// Рассчитываем позицию заголовка
 	titleBounds := text.BoundString(mm.fontFace, mm.title)
-	titleX := (WinWidth - titleBounds.Dx()) / 2
+	titleX := (w - titleBounds.Dx()) / 2
 	titleY := 100
 
 	// Рисуем заголовок
@@ -102,7 +104,7 @@ func (mm *MainMenu) Draw(screen *ebiten.Image) {
 		}
 
 		optionBounds := text.BoundString(mm.fontFace, option)
-		x := (WinWidth - optionBounds.Dx()) / 2
+		x := (w - optionBounds.Dx()) / 2
 		y := 200 + i*40
 
 		text.Draw(screen, option, mm.fontFace, x, y, col)
@@ -110,6 +112,6 @@ func (mm *MainMenu) Draw(screen *ebiten.Image) {
 
 	// Рисуем версию игры в углу
 	versionX := 20
-	versionY := WinHeight - 20
+	versionY := h - 20
 	text.Draw(screen, mm.version, mm.fontFace, versionX, versionY, gray)
 }
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"container/heap"
+	"math"
+)
+
+// TilePoint - координаты клетки на сетке уровня
+type TilePoint struct {
+	X, Y int
+}
+
+// Стоимость прохода по типам тайлов (0 - непроходимо)
+var tileMoveCost = map[TileType]float64{
+	TileGrass: 1,
+	TileSand:  2, // По песку идти медленнее
+	TileStone: 1,
+	TileWater: 0,
+	TileTree:  0,
+	TileWall:  0,
+	TileFloor: 1,
+}
+
+// NavGrid - сетка проходимости уровня
+type NavGrid struct {
+	Width, Height         int
+	TileWidth, TileHeight int
+	cost                  []float64
+	// Бесконечная карта хранит стоимости кусками NavChunkSize, как слои
+	// хранят тайлы: куски заводятся только там, где стоимость не 1
+	chunks map[TilePoint][]float64
+}
+
+// NewNavGrid строит сетку проходимости из Level.Map или слоев коллизий Tiled
+func NewNavGrid(level *Level) *NavGrid {
+	grid := &NavGrid{
+		Width:      level.Width,
+		Height:     level.Height,
+		TileWidth:  tileSize,
+		TileHeight: tileSize,
+	}
+	if level.TiledMap != nil {
+		grid.TileWidth = level.TiledMap.TileWidth
+		grid.TileHeight = level.TiledMap.TileHeight
+	}
+
+	if level.TiledMap != nil && level.TiledMap.Infinite {
+		grid.chunks = make(map[TilePoint][]float64)
+	} else {
+		grid.cost = make([]float64, grid.Width*grid.Height)
+		for i := range grid.cost {
+			grid.cost[i] = 1
+		}
+	}
+
+	// Ручная карта
+	for y := 0; y < len(level.Map) && y < grid.Height; y++ {
+		for x := 0; x < len(level.Map[y]) && x < grid.Width; x++ {
+			cost, ok := tileMoveCost[level.Map[y][x]]
+			if !ok {
+				cost = 1
+			}
+			grid.SetCost(x, y, cost)
+		}
+	}
+
+	// Слои коллизий из Tiled: любой непустой тайл непроходим
+	if level.TiledMap != nil {
+		for _, layer := range level.TiledMap.AllLayers() {
+			if layer.Type != "tilelayer" || !isCollisionLayer(layer) {
+				continue
+			}
+			layer.forEachTile(func(x, y, gid int) {
+				grid.SetCost(x, y, 0)
+			})
+		}
+	}
+
+	return grid
+}
+
+// isCollisionLayer проверяет, является ли слой слоем коллизий
+func isCollisionLayer(layer Layer) bool {
+	if layer.Name == "collision" {
+		return true
+	}
+	collision, _ := propertyBool(layer.Properties, "collision")
+	return collision
+}
+
+func (n *NavGrid) inBounds(x, y int) bool {
+	return x >= 0 && y >= 0 && x < n.Width && y < n.Height
+}
+
+// Walkable сообщает, можно ли пройти через клетку
+func (n *NavGrid) Walkable(x, y int) bool {
+	return n.Cost(x, y) > 0
+}
+
+// Cost возвращает стоимость входа в клетку
+func (n *NavGrid) Cost(x, y int) float64 {
+	if !n.inBounds(x, y) {
+		return 0
+	}
+	if n.chunks == nil {
+		return n.cost[y*n.Width+x]
+	}
+	chunk, ok := n.chunks[TilePoint{X: x / NavChunkSize, Y: y / NavChunkSize}]
+	if !ok {
+		return 1
+	}
+	return chunk[(y%NavChunkSize)*NavChunkSize+x%NavChunkSize]
+}
+
+// SetCost меняет стоимость клетки (например, при открытии двери)
+func (n *NavGrid) SetCost(x, y int, cost float64) {
+	if !n.inBounds(x, y) {
+		return
+	}
+	if n.chunks == nil {
+		n.cost[y*n.Width+x] = cost
+		return
+	}
+	key := TilePoint{X: x / NavChunkSize, Y: y / NavChunkSize}
+	chunk, ok := n.chunks[key]
+	if !ok {
+		if cost == 1 {
+			return
+		}
+		chunk = make([]float64, NavChunkSize*NavChunkSize)
+		for i := range chunk {
+			chunk[i] = 1
+		}
+		n.chunks[key] = chunk
+	}
+	chunk[(y%NavChunkSize)*NavChunkSize+x%NavChunkSize] = cost
+}
+
+// ToTile переводит мировые координаты в клетку
+func (n *NavGrid) ToTile(pos Position) TilePoint {
+	return TilePoint{
+		X: int(math.Floor(pos.X / float64(n.TileWidth))),
+		Y: int(math.Floor(pos.Y / float64(n.TileHeight))),
+	}
+}
+
+// ToWorld возвращает центр клетки в мировых координатах
+func (n *NavGrid) ToWorld(p TilePoint) Position {
+	return Position{
+		X: (float64(p.X) + 0.5) * float64(n.TileWidth),
+		Y: (float64(p.Y) + 0.5) * float64(n.TileHeight),
+	}
+}
+
+type pathKey struct {
+	from, to TilePoint
+}
+
+// Pathfinder ищет пути алгоритмом A* с кэшированием результатов
+type Pathfinder struct {
+	grid      *NavGrid
+	diagonal  bool // 8-связность вместо 4-связности
+	cache     map[pathKey][]TilePoint
+	cacheSize int
+}
+
+func NewPathfinder(grid *NavGrid, diagonal bool) *Pathfinder {
+	return &Pathfinder{
+		grid:      grid,
+		diagonal:  diagonal,
+		cache:     make(map[pathKey][]TilePoint),
+		cacheSize: PathCacheSize,
+	}
+}
+
+// Grid возвращает сетку проходимости
+func (pf *Pathfinder) Grid() *NavGrid {
+	return pf.grid
+}
+
+// Invalidate сбрасывает кэш после изменения сетки
+func (pf *Pathfinder) Invalidate() {
+	pf.cache = make(map[pathKey][]TilePoint)
+}
+
+// FindPath возвращает сглаженный путь в мировых координатах (без стартовой точки).
+// Если путь не найден, возвращает nil.
+func (pf *Pathfinder) FindPath(from, to Position) []Position {
+	start := pf.grid.ToTile(from)
+	goal := pf.grid.ToTile(to)
+
+	tiles := pf.FindTilePath(start, goal)
+	if tiles == nil {
+		return nil
+	}
+	// В одной клетке с целью идем прямо к ней
+	if start == goal {
+		return []Position{to}
+	}
+
+	tiles = pf.smooth(tiles)
+	path := make([]Position, 0, len(tiles))
+	for _, t := range tiles[1:] {
+		path = append(path, pf.grid.ToWorld(t))
+	}
+	// Последняя точка - точная цель, а не центр клетки
+	if len(path) > 0 {
+		path[len(path)-1] = to
+	}
+	return path
+}
+
+// FindTilePath возвращает путь по клеткам, включая старт и цель
+func (pf *Pathfinder) FindTilePath(start, goal TilePoint) []TilePoint {
+	if !pf.grid.Walkable(start.X, start.Y) || !pf.grid.Walkable(goal.X, goal.Y) {
+		return nil
+	}
+
+	key := pathKey{start, goal}
+	if cached, ok := pf.cache[key]; ok {
+		return cached
+	}
+
+	path := pf.astar(start, goal)
+
+	if len(pf.cache) >= pf.cacheSize {
+		pf.Invalidate()
+	}
+	pf.cache[key] = path
+	return path
+}
+
+var (
+	orthogonalSteps = []TilePoint{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+	diagonalSteps   = []TilePoint{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+)
+
+func (pf *Pathfinder) astar(start, goal TilePoint) []TilePoint {
+	w := pf.grid.Width
+	index := func(p TilePoint) int { return p.Y*w + p.X }
+
+	gScore := map[int]float64{index(start): 0}
+	cameFrom := make(map[int]TilePoint)
+	closed := make(map[int]bool)
+
+	open := &nodeHeap{}
+	heap.Push(open, &pathNode{point: start, f: pf.heuristic(start, goal)})
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(*pathNode)
+		ci := index(current.point)
+		if closed[ci] {
+			continue
+		}
+		closed[ci] = true
+
+		if current.point == goal {
+			return reconstructPath(cameFrom, current.point, start, index)
+		}
+
+		for _, step := range pf.neighbors() {
+			next := TilePoint{current.point.X + step.X, current.point.Y + step.Y}
+			if !pf.grid.Walkable(next.X, next.Y) {
+				continue
+			}
+
+			diagonal := step.X != 0 && step.Y != 0
+			// Не срезаем углы препятствий по диагонали
+			if diagonal && (!pf.grid.Walkable(current.point.X+step.X, current.point.Y) ||
+				!pf.grid.Walkable(current.point.X, current.point.Y+step.Y)) {
+				continue
+			}
+
+			stepLen := 1.0
+			if diagonal {
+				stepLen = math.Sqrt2
+			}
+
+			ni := index(next)
+			tentative := gScore[ci] + stepLen*pf.grid.Cost(next.X, next.Y)
+			if old, ok := gScore[ni]; ok && tentative >= old {
+				continue
+			}
+
+			gScore[ni] = tentative
+			cameFrom[ni] = current.point
+			heap.Push(open, &pathNode{point: next, f: tentative + pf.heuristic(next, goal)})
+		}
+	}
+
+	return nil
+}
+
+func (pf *Pathfinder) neighbors() []TilePoint {
+	if pf.diagonal {
+		return append(append([]TilePoint{}, orthogonalSteps...), diagonalSteps...)
+	}
+	return orthogonalSteps
+}
+
+// heuristic - манхэттенское расстояние для 4-связности и октильное для 8-связности
+func (pf *Pathfinder) heuristic(a, b TilePoint) float64 {
+	dx := math.Abs(float64(a.X - b.X))
+	dy := math.Abs(float64(a.Y - b.Y))
+	if !pf.diagonal {
+		return dx + dy
+	}
+	return math.Max(dx, dy) + (math.Sqrt2-1)*math.Min(dx, dy)
+}
+
+func reconstructPath(cameFrom map[int]TilePoint, current, start TilePoint, index func(TilePoint) int) []TilePoint {
+	path := []TilePoint{current}
+	for current != start {
+		current = cameFrom[index(current)]
+		path = append(path, current)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// smooth убирает промежуточные точки, между которыми есть прямая видимость
+// и не меняется стоимость местности
+func (pf *Pathfinder) smooth(path []TilePoint) []TilePoint {
+	if len(path) <= 2 {
+		return path
+	}
+
+	result := []TilePoint{path[0]}
+	anchor := 0
+	for i := 2; i < len(path); i++ {
+		if !pf.lineOfSight(path[anchor], path[i]) {
+			anchor = i - 1
+			result = append(result, path[anchor])
+		}
+	}
+	return append(result, path[len(path)-1])
+}
+
+// lineOfSight проверяет прямую между клетками алгоритмом Брезенхэма
+func (pf *Pathfinder) lineOfSight(a, b TilePoint) bool {
+	baseCost := pf.grid.Cost(a.X, a.Y)
+	dx := absInt(b.X - a.X)
+	dy := -absInt(b.Y - a.Y)
+	sx, sy := 1, 1
+	if a.X > b.X {
+		sx = -1
+	}
+	if a.Y > b.Y {
+		sy = -1
+	}
+
+	x, y := a.X, a.Y
+	err := dx + dy
+	for {
+		if !pf.grid.Walkable(x, y) || pf.grid.Cost(x, y) != baseCost {
+			return false
+		}
+		if x == b.X && y == b.Y {
+			return true
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y += sy
+		}
+	}
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// Очередь с приоритетом для A*
+type pathNode struct {
+	point TilePoint
+	f     float64
+}
+
+type nodeHeap []*pathNode
+
+func (h nodeHeap) Len() int            { return len(h) }
+func (h nodeHeap) Less(i, j int) bool  { return h[i].f < h[j].f }
+func (h nodeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *nodeHeap) Push(x interface{}) { *h = append(*h, x.(*pathNode)) }
+func (h *nodeHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
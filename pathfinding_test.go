@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// testGrid строит сетку из строк: '#' - стена, 's' - песок (стоимость 2),
+// остальное - проходимо. sparse хранит стоимости кусками, как у бесконечных карт.
+func testGrid(rows []string, sparse bool) *NavGrid {
+	grid := &NavGrid{Width: len(rows[0]), Height: len(rows), TileWidth: 32, TileHeight: 32}
+	if sparse {
+		grid.chunks = make(map[TilePoint][]float64)
+	} else {
+		grid.cost = make([]float64, grid.Width*grid.Height)
+	}
+	for y, row := range rows {
+		for x, c := range row {
+			switch c {
+			case '#':
+				grid.SetCost(x, y, 0)
+			case 's':
+				grid.SetCost(x, y, 2)
+			default:
+				grid.SetCost(x, y, 1)
+			}
+		}
+	}
+	return grid
+}
+
+func TestFindTilePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		rows     []string
+		diagonal bool
+		start    TilePoint
+		goal     TilePoint
+		length   int // Клеток в пути вместе со стартом и целью; 0 - пути нет
+	}{
+		{
+			name:   "straight line",
+			rows:   []string{"....."},
+			start:  TilePoint{0, 0},
+			goal:   TilePoint{4, 0},
+			length: 5,
+		},
+		{
+			name:   "around a wall",
+			rows:   []string{"..#..", "..#..", "....."},
+			start:  TilePoint{0, 0},
+			goal:   TilePoint{4, 0},
+			length: 9,
+		},
+		{
+			name:   "walled off",
+			rows:   []string{"..#..", "..#..", "..#.."},
+			start:  TilePoint{0, 0},
+			goal:   TilePoint{4, 0},
+			length: 0,
+		},
+		{
+			name:   "goal in a wall",
+			rows:   []string{"..#"},
+			start:  TilePoint{0, 0},
+			goal:   TilePoint{2, 0},
+			length: 0,
+		},
+		{
+			name:   "sand is avoided when a detour is cheaper",
+			rows:   []string{".sss.", "....."},
+			start:  TilePoint{0, 0},
+			goal:   TilePoint{4, 0},
+			length: 7,
+		},
+		{
+			name:     "diagonal",
+			rows:     []string{"...", "...", "..."},
+			diagonal: true,
+			start:    TilePoint{0, 0},
+			goal:     TilePoint{2, 2},
+			length:   3,
+		},
+		{
+			name:     "diagonal does not cut corners",
+			rows:     []string{".#", ".."},
+			diagonal: true,
+			start:    TilePoint{0, 0},
+			goal:     TilePoint{1, 1},
+			length:   3,
+		},
+		{
+			name:   "start is the goal",
+			rows:   []string{"..."},
+			start:  TilePoint{1, 0},
+			goal:   TilePoint{1, 0},
+			length: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		for _, sparse := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/sparse=%v", tt.name, sparse), func(t *testing.T) {
+				pf := NewPathfinder(testGrid(tt.rows, sparse), tt.diagonal)
+				path := pf.FindTilePath(tt.start, tt.goal)
+				if len(path) != tt.length {
+					t.Fatalf("path %v has %d tiles, want %d", path, len(path), tt.length)
+				}
+				if tt.length == 0 {
+					return
+				}
+				if path[0] != tt.start || path[len(path)-1] != tt.goal {
+					t.Errorf("path %v does not go from %v to %v", path, tt.start, tt.goal)
+				}
+				for _, p := range path {
+					if !pf.Grid().Walkable(p.X, p.Y) {
+						t.Errorf("path %v goes through the wall at %v", path, p)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestFindPathSameTile(t *testing.T) {
+	pf := NewPathfinder(testGrid([]string{"..."}, false), false)
+	to := Position{X: 40, Y: 20}
+	path := pf.FindPath(Position{X: 35, Y: 10}, to)
+	if len(path) != 1 || path[0] != to {
+		t.Errorf("FindPath in the goal tile = %v, want [%v]", path, to)
+	}
+}
+
+func TestSparseNavGridDefaults(t *testing.T) {
+	grid := testGrid([]string{"................................"}, true)
+	if len(grid.chunks) != 0 {
+		t.Errorf("open grid allocated %d chunks, want 0", len(grid.chunks))
+	}
+	grid.SetCost(20, 0, 0)
+	if len(grid.chunks) != 1 || grid.Walkable(20, 0) || !grid.Walkable(19, 0) {
+		t.Errorf("wall at (20, 0): %d chunks, walkable %v, neighbour walkable %v",
+			len(grid.chunks), grid.Walkable(20, 0), grid.Walkable(19, 0))
+	}
+	if grid.Walkable(-1, 0) || grid.Walkable(32, 0) {
+		t.Error("cells outside the grid are walkable")
+	}
+}
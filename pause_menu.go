@@ -0,0 +1,162 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+)
+
+const (
+	pausePageMain = iota
+	pausePageOptions
+)
+
+type PauseMenu struct {
+	page      int
+	selected  int
+	fontFace  font.Face
+	autoPause bool // Пауза при потере фокуса окном
+}
+
+func NewPauseMenu() *PauseMenu {
+	return &PauseMenu{
+		fontFace:  basicfont.Face7x13,
+		autoPause: true,
+	}
+}
+
+// options возвращает пункты текущей страницы меню
+func (pm *PauseMenu) options(g *Game) []string {
+	if pm.page == pausePageOptions {
+		return []string{
+			"Debug overlay: " + onOff(g.screenManager.debug),
+			"Facing indicator: " + onOff(g.screenManager.showFacing),
+			"Lean sprite: " + onOff(g.screenManager.leanSprite),
+			"Pause on focus loss: " + onOff(pm.autoPause),
+			"Resolution: " + g.display.Resolution().String(),
+			"Pixel perfect: " + onOff(g.display.pixelPerfect),
+			"Fullscreen: " + onOff(ebiten.IsFullscreen()),
+			"Back",
+		}
+	}
+	return []string{
+		"Resume",
+		"Options",
+		"Restart Level",
+		"Quit to Menu",
+	}
+}
+
+func onOff(v bool) string {
+	if v {
+		return "On"
+	}
+	return "Off"
+}
+
+// PauseMenu - сцена-оверлей поверх игрового процесса
+func (pm *PauseMenu) IsOverlay() bool { return true }
+
+func (pm *PauseMenu) Enter(g *Game) {
+	gameClock.Pause()
+	pm.openPage(pausePageMain)
+}
+
+func (pm *PauseMenu) Exit(g *Game) {
+	gameClock.Resume()
+}
+
+func (pm *PauseMenu) Update(g *Game) error {
+	options := pm.options(g)
+
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyDown):
+		pm.selected = (pm.selected + 1) % len(options)
+	case inpututil.IsKeyJustPressed(ebiten.KeyUp):
+		pm.selected = (pm.selected - 1 + len(options)) % len(options)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeySpace):
+		pm.handleSelection(g)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape) || inpututil.IsKeyJustPressed(ebiten.KeyP):
+		if pm.page == pausePageOptions {
+			pm.openPage(pausePageMain)
+		} else {
+			g.Resume()
+		}
+	}
+	return nil
+}
+
+func (pm *PauseMenu) openPage(page int) {
+	pm.page = page
+	pm.selected = 0
+}
+
+func (pm *PauseMenu) handleSelection(g *Game) {
+	if pm.page == pausePageOptions {
+		switch pm.selected {
+		case 0:
+			g.screenManager.debug = !g.screenManager.debug
+		case 1:
+			g.screenManager.showFacing = !g.screenManager.showFacing
+		case 2:
+			g.screenManager.leanSprite = !g.screenManager.leanSprite
+		case 3:
+			pm.autoPause = !pm.autoPause
+		case 4:
+			g.display.NextResolution()
+		case 5:
+			g.display.TogglePixelPerfect()
+		case 6:
+			g.display.ToggleFullscreen()
+		default:
+			pm.openPage(pausePageMain)
+		}
+		return
+	}
+
+	switch pm.options(g)[pm.selected] {
+	case "Resume":
+		g.Resume()
+	case "Options":
+		pm.openPage(pausePageOptions)
+	case "Restart Level":
+		g.RestartLevel()
+	case "Quit to Menu":
+		g.QuitToMenu()
+	}
+}
+
+func (pm *PauseMenu) Draw(screen *ebiten.Image, g *Game) {
+	// Затемняем игровой экран под меню
+	w, h := g.display.Width(), g.display.Height()
+	ebitenutil.DrawRect(screen, 0, 0, float64(w), float64(h), color.RGBA{0, 0, 0, 160})
+
+	white := color.White
+	yellow := color.NRGBA{255, 200, 0, 255}
+
+	title := "PAUSED"
+	if pm.page == pausePageOptions {
+		title = "OPTIONS"
+	}
+	titleBounds := text.BoundString(pm.fontFace, title)
+	text.Draw(screen, title, pm.fontFace, (w-titleBounds.Dx())/2, h/2-100, white)
+
+	var col color.Color
+	for i, option := range pm.options(g) {
+		col = white
+		if i == pm.selected {
+			col = yellow
+		}
+
+		optionBounds := text.BoundString(pm.fontFace, option)
+		x := (w - optionBounds.Dx()) / 2
+		y := h/2 - 40 + i*40
+
+		text.Draw(screen, option, pm.fontFace, x, y, col)
+	}
+}
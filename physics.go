@@ -0,0 +1,93 @@
+package main
+
+import "math"
+
+// Body - простое кинематическое тело: скорость, ускорение, трение и
+// отдельная составляющая отбрасывания, которая не ограничена MaxSpeed.
+type Body struct {
+	Velocity     Position
+	Knockback    Position
+	Acceleration float64 // Прирост скорости за тик при полном вводе
+	Friction     float64 // Доля скорости, теряемая за тик (0..1)
+	MaxSpeed     float64
+
+	input Position // Направление ввода за текущий тик
+}
+
+func NewBody(maxSpeed float64) Body {
+	return Body{
+		Acceleration: maxSpeed * BodyAccelerationFactor,
+		Friction:     BodyFriction,
+		MaxSpeed:     maxSpeed,
+	}
+}
+
+// Accelerate добавляет направление движения на текущий тик
+func (b *Body) Accelerate(dx, dy float64) {
+	b.input.X += dx
+	b.input.Y += dy
+}
+
+// Impulse добавляет мгновенную скорость отбрасывания
+func (b *Body) Impulse(v Position) {
+	b.Knockback.X += v.X
+	b.Knockback.Y += v.Y
+}
+
+// Speed возвращает модуль управляемой скорости
+func (b *Body) Speed() float64 {
+	return math.Hypot(b.Velocity.X, b.Velocity.Y)
+}
+
+// Stop обнуляет скорость (например, при рестарте или телепорте)
+func (b *Body) Stop() {
+	b.Velocity = Position{}
+	b.Knockback = Position{}
+	b.input = Position{}
+}
+
+// Step интегрирует движение за один тик по местности t и возвращает смещение.
+// Трение местности меньше 1 - скользко, больше 1 - вязко: вязкая местность
+// снижает и предел скорости. Speed ограничивает скорость, а на льду
+// (Slippery) еще и медленнее разгон.
+func (b *Body) Step(t Terrain) (dx, dy float64) {
+	// Нормализуем ввод, чтобы по диагонали не было быстрее
+	if l := math.Hypot(b.input.X, b.input.Y); l > 1 {
+		b.input.X /= l
+		b.input.Y /= l
+	}
+
+	friction := clampFloat(b.Friction*t.Friction, 0, 1)
+	accel := b.Acceleration * t.Speed
+	if t.Slippery {
+		accel *= SlipperyGrip
+	}
+	b.Velocity.X = b.Velocity.X*(1-friction) + b.input.X*accel
+	b.Velocity.Y = b.Velocity.Y*(1-friction) + b.input.Y*accel
+	b.input = Position{}
+
+	maxSpeed := b.MaxSpeed * t.Speed
+	if t.Friction > 1 {
+		maxSpeed /= t.Friction
+	}
+	if speed := b.Speed(); speed > maxSpeed {
+		b.Velocity.X *= maxSpeed / speed
+		b.Velocity.Y *= maxSpeed / speed
+	}
+
+	// Гасим остаточное дрожание
+	if b.Speed() < BodyRestSpeed {
+		b.Velocity = Position{}
+	}
+
+	dx = b.Velocity.X + b.Knockback.X
+	dy = b.Velocity.Y + b.Knockback.Y
+
+	b.Knockback.X *= 1 - KnockbackDamping
+	b.Knockback.Y *= 1 - KnockbackDamping
+	if math.Hypot(b.Knockback.X, b.Knockback.Y) < BodyRestSpeed {
+		b.Knockback = Position{}
+	}
+
+	return dx, dy
+}
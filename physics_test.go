@@ -0,0 +1,96 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+var iceTerrain = Terrain{Speed: 1, Friction: SlipperyFriction, Slippery: true}
+
+// runBody разгоняет тело по направлению (dx, dy) ticks тиков
+func runBody(b *Body, t Terrain, dx, dy float64, ticks int) {
+	for i := 0; i < ticks; i++ {
+		b.Accelerate(dx, dy)
+		b.Step(t)
+	}
+}
+
+func TestBodyTopSpeed(t *testing.T) {
+	const maxSpeed = 4.0
+	tests := []struct {
+		name    string
+		terrain Terrain
+		want    float64
+	}{
+		{"default", defaultTerrain, maxSpeed},
+		{"grass", tileTerrain[TileGrass], maxSpeed},
+		{"sand is slower and its friction lowers the limit", tileTerrain[TileSand], maxSpeed * 0.75 / 1.5},
+		{"water", tileTerrain[TileWater], maxSpeed * 0.5},
+		{"ice keeps the limit", iceTerrain, maxSpeed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBody(maxSpeed)
+			runBody(&b, tt.terrain, 1, 0, 200)
+			if got := b.Speed(); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("top speed = %v, want %v", got, tt.want)
+			}
+
+			// По диагонали не быстрее
+			b = NewBody(maxSpeed)
+			runBody(&b, tt.terrain, 1, 1, 200)
+			if got := b.Speed(); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("diagonal top speed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// stopTicks считает тики до полной остановки после отпускания ввода
+func stopTicks(t *testing.T, terrain Terrain) int {
+	t.Helper()
+	b := NewBody(4)
+	runBody(&b, terrain, 1, 0, 200)
+	for ticks := 1; ticks < 1000; ticks++ {
+		b.Step(terrain)
+		if b.Velocity == (Position{}) {
+			return ticks
+		}
+	}
+	t.Fatalf("body on %+v did not stop", terrain)
+	return 0
+}
+
+func TestBodyFriction(t *testing.T) {
+	grass := stopTicks(t, tileTerrain[TileGrass])
+	sand := stopTicks(t, tileTerrain[TileSand])
+	ice := stopTicks(t, iceTerrain)
+	if !(sand < grass && grass < ice) {
+		t.Errorf("ticks to stop: sand %d, grass %d, ice %d; want sand < grass < ice", sand, grass, ice)
+	}
+
+	// На льду разгон медленнее
+	b, onIce := NewBody(4), NewBody(4)
+	b.Accelerate(1, 0)
+	b.Step(defaultTerrain)
+	onIce.Accelerate(1, 0)
+	onIce.Step(iceTerrain)
+	if onIce.Speed() >= b.Speed() {
+		t.Errorf("first tick speed on ice %v, on ground %v; want slower on ice", onIce.Speed(), b.Speed())
+	}
+}
+
+func TestBodyKnockback(t *testing.T) {
+	b := NewBody(4)
+	b.Impulse(Position{X: 20})
+	dx, dy := b.Step(defaultTerrain)
+	if dx != 20 || dy != 0 {
+		t.Fatalf("first knockback step = (%v, %v), want (20, 0) past MaxSpeed", dx, dy)
+	}
+	for i := 0; i < 100; i++ {
+		b.Step(defaultTerrain)
+	}
+	if b.Knockback != (Position{}) {
+		t.Errorf("knockback %v did not fade", b.Knockback)
+	}
+}
@@ -14,7 +14,14 @@ type Player struct {
 
 	// Состояния
 	state     string // "standing", "running", "attacking"
-	direction string // "forward", "back", "left", "right"
+	direction string // "forward", "back", "left", "right" и диагонали вида "forward_right"
+
+	// Физика и перемещение по клику
+	body           Body
+	terrain        Terrain   // Местность под игроком на последнем тике
+	lastStep       time.Time // Последний звук шага
+	path           []Position
+	worldW, worldH float64 // Границы уровня
 
 	// Анимация
 	animFrame      int
@@ -31,11 +38,18 @@ type Player struct {
 	maxHealth       int
 	damageQueue     []int // Очередь полученного урона
 	lastDamageTime  time.Time
+	lastUpdate      time.Time     // Время предыдущего Update для таймера мигания
 	invulnerable    bool          // Флаг неуязвимости
 	invulnStartTime time.Time     // Время начала неуязвимости
 	invulnDuration  time.Duration // Длительность неуязвимости
 	blinkTimer      time.Duration // Таймер мигания
 	visible         bool          // Видимость при мигании
+
+	// Сопротивления урону (0 - нет, 1 - полный иммунитет)
+	resistances map[DamageType]float64
+	godMode     bool // Режим бога (-god): урон не проходит
+
+	keys map[int]int // Собранные ключи по KeyID
 }
 
 func NewPlayer() *Player {
@@ -50,11 +64,17 @@ func NewPlayer() *Player {
 		invulnerable:   false,
 		invulnDuration: PlayerInvulnDuration, // Константа из consts.go
 		visible:        true,
+		body:           NewBody(MoveSpeed),
+		worldW:         WinWidth,
+		worldH:         WinHeight,
+		resistances: map[DamageType]float64{
+			DamagePoison: 0.25,
+		},
 	}
 }
 
 func (p *Player) Update() {
-	now := time.Now()
+	now := gameClock.Now()
 
 	// Обновление статуса неуязвимости
 	if p.invulnerable && now.Sub(p.invulnStartTime) > p.invulnDuration {
@@ -64,14 +84,14 @@ func (p *Player) Update() {
 
 	// Мигание при неуязвимости
 	if p.invulnerable {
-		p.blinkTimer -= time.Since(p.lastDamageTime)
+		p.blinkTimer -= now.Sub(p.lastUpdate)
 		if p.blinkTimer <= 0 {
 			p.visible = !p.visible
 			p.blinkTimer = PlayerBlinkInterval // Константа из consts.go
 		}
 	}
 
-	p.lastDamageTime = now
+	p.lastUpdate = now
 
 	// Обновление анимации (только если не атакуем)
 	if !p.attacking && now.Sub(p.animLastUpdate) > time.Second/time.Duration(AnimationFPS) {
@@ -85,10 +105,13 @@ func (p *Player) Update() {
 	// Автоматическая стабилизация наклона
 	p.UpdateLean()
 
-	if len(p.damageQueue) > 0 && time.Since(p.lastDamageTime) > time.Second {
+	// Направление спрайта определяется углом поворота
+	p.direction = p.FacingDirection()
+
+	if len(p.damageQueue) > 0 && gameClock.Since(p.lastDamageTime) > time.Second {
 		p.damageQueue = p.damageQueue[1:] // Удаляем обработанный урон
 		if len(p.damageQueue) > 0 {
-			p.lastDamageTime = time.Now()
+			p.lastDamageTime = gameClock.Now()
 		}
 	}
 }
@@ -98,7 +121,7 @@ func (p *Player) UpdateAttack() {
 		return
 	}
 
-	now := time.Now()
+	now := gameClock.Now()
 	if now.Sub(p.attackStartTime) > time.Second/time.Duration(AttackFPS) {
 		p.attackFrame++
 		p.attackStartTime = now
@@ -128,7 +151,7 @@ func (p *Player) CanAttack() bool {
 	// Можно атаковать, если:
 	// 1. Уже не атакуем
 	// 2. Прошел кулдаун после последней атаки
-	return !p.attacking && time.Since(p.lastAttackTime) > AttackCooldown
+	return !p.attacking && gameClock.Since(p.lastAttackTime) > AttackCooldown
 }
 
 func (p *Player) Attack() {
@@ -139,8 +162,8 @@ func (p *Player) Attack() {
 	p.attacking = true
 	p.state = "attacking"
 	p.attackFrame = 0
-	p.attackStartTime = time.Now()
-	p.lastAttackTime = time.Now()
+	p.attackStartTime = gameClock.Now()
+	p.lastAttackTime = gameClock.Now()
 }
 
 func (p *Player) Move(direction float64) {
@@ -149,18 +172,8 @@ func (p *Player) Move(direction float64) {
 	}
 
 	p.state = "running"
-	rad := float64(p.angle) * 2 * math.Pi / MaxAngle
-	p.x += MoveSpeed * math.Cos(rad) * direction
-	p.y += MoveSpeed * math.Sin(rad) * direction
-
-	// Обновляем направление спрайта
-	if direction > 0 {
-		p.direction = "forward" // Движение вперед (от игрока)
-	} else {
-		p.direction = "back" // Движение назад (к игроку)
-	}
-
-	p.clampPosition()
+	rad := p.FacingAngle()
+	p.body.Accelerate(math.Cos(rad)*direction, math.Sin(rad)*direction)
 }
 
 func (p *Player) Strafe(direction float64) {
@@ -170,16 +183,65 @@ func (p *Player) Strafe(direction float64) {
 
 	p.state = "running"
 	// Движение строго по горизонтали без учета угла поворота
-	p.x += MoveSpeed * direction
+	p.body.Accelerate(direction, 0)
+}
 
-	// Обновляем направление спрайта
-	if direction > 0 {
-		p.direction = "right" // Движение вправо
-	} else {
-		p.direction = "left" // Движение влево
+// ApplyPhysics перемещает игрока по скорости тела с учетом местности под ним
+func (p *Player) ApplyPhysics(t Terrain, blocked func(image.Rectangle) bool) {
+	p.terrain = t
+	dx, dy := p.body.Step(t)
+	p.moveAxis(dx, 0, blocked)
+	p.moveAxis(0, dy, blocked)
+	p.clampPosition()
+}
+
+// moveAxis сдвигает игрока, если хитбокс не заходит в стену.
+// Из положения, которое уже пересекает стену, выйти можно.
+func (p *Player) moveAxis(dx, dy float64, blocked func(image.Rectangle) bool) {
+	if dx == 0 && dy == 0 {
+		return
+	}
+	stuck := blocked != nil && blocked(p.GetCollisionRect())
+	p.x += dx
+	p.y += dy
+	if blocked == nil || stuck || !blocked(p.GetCollisionRect()) {
+		return
 	}
 
-	p.clampPosition()
+	p.x -= dx
+	p.y -= dy
+	if dx != 0 {
+		p.body.Velocity.X, p.body.Knockback.X = 0, 0
+	}
+	if dy != 0 {
+		p.body.Velocity.Y, p.body.Knockback.Y = 0, 0
+	}
+}
+
+// AddKey добавляет ключ в инвентарь
+func (p *Player) AddKey(id int) {
+	if p.keys == nil {
+		p.keys = make(map[int]int)
+	}
+	p.keys[id]++
+}
+
+// UseKey тратит ключ id, если он есть
+func (p *Player) UseKey(id int) bool {
+	if p.keys[id] == 0 {
+		return false
+	}
+	p.keys[id]--
+	return true
+}
+
+// KeyCount возвращает общее число ключей
+func (p *Player) KeyCount() int {
+	n := 0
+	for _, c := range p.keys {
+		n += c
+	}
+	return n
 }
 
 func (p *Player) Rotate(direction int) {
@@ -190,6 +252,88 @@ func (p *Player) Rotate(direction int) {
 	p.lean = clampInt(p.lean+direction, -MaxLean, MaxLean)
 }
 
+// FacingAngle возвращает угол поворота в радианах (0 - вправо, по часовой стрелке)
+func (p *Player) FacingAngle() float64 {
+	return float64(p.angle) * 2 * math.Pi / MaxAngle
+}
+
+// FacingDirection переводит угол поворота в одно из FacingSectors направлений
+func (p *Player) FacingDirection() string {
+	sectors := 4
+	if FacingSectors == 8 {
+		sectors = 8
+	}
+
+	// Сдвигаем на половину сектора, чтобы направление было в центре сектора
+	sectorSize := MaxAngle / sectors
+	sector := ((p.angle + sectorSize/2) % MaxAngle) / sectorSize
+
+	if sectors == 4 {
+		return [...]string{"right", "back", "left", "forward"}[sector]
+	}
+	return [...]string{
+		"right", "back_right", "back", "back_left",
+		"left", "forward_left", "forward", "forward_right",
+	}[sector]
+}
+
+// SectorOffset возвращает отклонение угла от центра текущего сектора в радианах
+func (p *Player) SectorOffset() float64 {
+	sectors := 4
+	if FacingSectors == 8 {
+		sectors = 8
+	}
+	sectorSize := MaxAngle / sectors
+	offset := (p.angle+sectorSize/2)%sectorSize - sectorSize/2
+	return float64(offset) * 2 * math.Pi / MaxAngle
+}
+
+// LeanTilt возвращает наклон спрайта в радианах
+func (p *Player) LeanTilt() float64 {
+	return float64(p.lean) / MaxLean * MaxLeanTilt
+}
+
+// Center возвращает центр хитбокса игрока
+func (p *Player) Center() Position {
+	r := p.GetCollisionRect()
+	return Position{
+		X: float64(r.Min.X+r.Max.X) / 2,
+		Y: float64(r.Min.Y+r.Max.Y) / 2,
+	}
+}
+
+// SetWorldBounds задает размер уровня, за который игрок не может выйти
+func (p *Player) SetWorldBounds(w, h float64) {
+	p.worldW, p.worldH = w, h
+}
+
+// SetPath задает путь для перемещения по клику
+func (p *Player) SetPath(path []Position) {
+	p.path = path
+}
+
+// FollowPath продвигает игрока к следующей точке пути.
+// Возвращает true, если игрок двигался.
+func (p *Player) FollowPath() bool {
+	if len(p.path) == 0 || p.attacking {
+		return false
+	}
+
+	center := p.Center()
+	target := p.path[0]
+
+	// Разворачиваемся лицом к точке пути
+	rad := math.Atan2(target.Y-center.Y, target.X-center.X)
+	p.angle = (int(math.Round(rad*MaxAngle/(2*math.Pi))) + MaxAngle) % MaxAngle
+
+	if distance(center, target) <= math.Max(WaypointReachRadius, p.body.Speed()) {
+		p.path = p.path[1:]
+	}
+	p.body.Accelerate(math.Cos(rad), math.Sin(rad))
+	p.state = "running"
+	return true
+}
+
 func (p *Player) Stop() {
 	if !p.attacking { // Не меняем состояние во время атаки
 		p.state = "standing"
@@ -200,8 +344,8 @@ func (p *Player) clampPosition() {
 	charWidth := float64(CharacterSprites[0].Bounds().Dx()) * CharScale
 	charHeight := float64(CharacterSprites[0].Bounds().Dy()) * CharScale
 
-	p.x = clampFloat(p.x, 0, float64(WinWidth)-charWidth)
-	p.y = clampFloat(p.y, 0, float64(WinHeight)-charHeight)
+	p.x = clampFloat(p.x, 0, p.worldW-charWidth)
+	p.y = clampFloat(p.y, 0, p.worldH-charHeight)
 }
 
 // Вспомогательные функции
@@ -240,23 +384,47 @@ func (p *Player) TakeDamage(amount int) {
 	if p.invulnerable || p.health <= 0 {
 		return
 	}
+	p.applyDamage(amount, true)
+}
 
+// applyDamage уменьшает здоровье без проверок кулдаунов.
+// Вызывается из DamageSystem и TakeDamage.
+func (p *Player) applyDamage(amount int, grantInvuln bool) {
 	p.health -= amount
-	p.lastDamageTime = time.Now()
-	p.activateInvulnerability() // Активируем неуязвимость
+	p.lastDamageTime = gameClock.Now()
+	p.damageQueue = append(p.damageQueue, amount)
 
-	// Визуальный эффект
-	p.blinkTimer = 0
-	p.visible = false // Начинаем с невидимости для мгновенной обратной связи
+	if grantInvuln {
+		p.activateInvulnerability() // Активируем неуязвимость
+
+		// Визуальный эффект
+		p.blinkTimer = 0
+		p.visible = false // Начинаем с невидимости для мгновенной обратной связи
+	}
 
 	if p.health <= 0 {
 		p.die()
 	}
 }
 
+// ResistedDamage возвращает урон с учетом сопротивления игрока
+func (p *Player) ResistedDamage(dtype DamageType, amount int) int {
+	resist := clampFloat(p.resistances[dtype], 0, 1)
+	return int(math.Round(float64(amount) * (1 - resist)))
+}
+
+// ApplyKnockback отбрасывает игрока с начальной скоростью v
+func (p *Player) ApplyKnockback(v Position) {
+	if v.X == 0 && v.Y == 0 {
+		return
+	}
+	p.path = nil
+	p.body.Impulse(v)
+}
+
 func (p *Player) activateInvulnerability() {
 	p.invulnerable = true
-	p.invulnStartTime = time.Now()
+	p.invulnStartTime = gameClock.Now()
 	p.visible = true
 	p.blinkTimer = 0
 }
@@ -271,11 +439,19 @@ func (p *Player) GetDrawOpacity() float64 {
 	if !p.invulnerable {
 		return 1.0
 	}
-	elapsed := time.Since(p.invulnStartTime).Seconds()
+	elapsed := gameClock.Since(p.invulnStartTime).Seconds()
 	progress := elapsed / p.invulnDuration.Seconds()
 	return 0.3 + 0.7*math.Abs(math.Sin(progress*math.Pi*10))
 }
 
+// FootY - нижний край спрайта игрока для сортировки по глубине
+func (p *Player) FootY() float64 {
+	if len(CharacterSprites) == 0 {
+		return p.y
+	}
+	return p.y + float64(CharacterSprites[0].Bounds().Dy())*CharScale
+}
+
 func (p *Player) GetCollisionRect() image.Rectangle {
 	width := int(math.Round(float64(SpriteWidth * CharScale)))
 	height := int(math.Round(float64(SpriteHeight * CharScale)))
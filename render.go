@@ -0,0 +1,104 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Глубина отрисовки слоя Tiled задается свойством слоя "depth"
+const (
+	depthBelow  = "below"  // Под персонажами (земля, дороги)
+	depthSorted = "sorted" // Вперемешку с персонажами по Y ног (деревья, дома)
+	depthAbove  = "above"  // Над всеми (кроны, крыши)
+)
+
+// layerDepth возвращает глубину слоя. По умолчанию тайловые слои
+// лежат под персонажами, а объекты сортируются вместе с ними.
+func layerDepth(layer Layer) string {
+	if v, ok := propertyString(layer.Properties, "depth"); ok {
+		switch v {
+		case depthBelow, depthSorted, depthAbove:
+			return v
+		}
+	}
+	if layer.Type == "objectgroup" {
+		return depthSorted
+	}
+	return depthBelow
+}
+
+// renderItem - один спрайт в очереди с Y его нижнего края
+type renderItem struct {
+	footY float64
+	draw  func(screen *ebiten.Image)
+}
+
+// RenderQueue рисует спрайты сверху вниз по Y ног: кто ниже на экране,
+// тот ближе к камере. При равном Y сохраняется порядок добавления.
+type RenderQueue struct {
+	items []renderItem
+}
+
+func (q *RenderQueue) Add(footY float64, draw func(screen *ebiten.Image)) {
+	q.items = append(q.items, renderItem{footY: footY, draw: draw})
+}
+
+// Flush рисует очередь и очищает ее
+func (q *RenderQueue) Flush(screen *ebiten.Image) {
+	sort.SliceStable(q.items, func(i, j int) bool {
+		return q.items[i].footY < q.items[j].footY
+	})
+	for _, item := range q.items {
+		item.draw(screen)
+	}
+	clear(q.items)
+	q.items = q.items[:0]
+}
+
+// queueEntities добавляет в очередь врагов и игрока
+func (g *Game) queueEntities(q *RenderQueue, level *Level) {
+	// Столкновения уже посчитаны в updatePlaying
+	contacts := make(map[int]bool, len(g.playerContacts))
+	for _, i := range g.playerContacts {
+		contacts[i] = true
+	}
+
+	for i := range level.Enemies {
+		if !g.simulated(level, &level.Enemies[i]) {
+			continue
+		}
+		enemy := level.Enemies[i]
+		contact := contacts[i]
+		q.Add(enemy.FootY(), func(screen *ebiten.Image) {
+			g.drawEnemy(screen, enemy, contact)
+		})
+	}
+
+	if g.player != nil && g.player.visible {
+		q.Add(g.player.FootY(), g.drawPlayer)
+	}
+}
+
+// queueTileLayer добавляет в очередь тайлы слоя; Y ног тайла - его нижний край
+func (g *Game) queueTileLayer(q *RenderQueue, level *Level, layer styledLayer) {
+	th := level.TiledMap.TileHeight
+	g.forEachVisibleTile(level, &layer, func(x, y, gid int) {
+		q.Add(float64((y+1)*th)+layer.style.offset.Y, func(screen *ebiten.Image) {
+			g.drawTile(screen, level, gid, x, y, &layer.style)
+		})
+	})
+}
+
+// queueObjectLayer добавляет в очередь тайловые объекты слоя.
+// У тайловых объектов Tiled Y - нижний край.
+func (g *Game) queueObjectLayer(q *RenderQueue, level *Level, layer styledLayer) {
+	for _, obj := range layer.Objects {
+		if obj.GID == 0 {
+			continue
+		}
+		q.Add(obj.Y+layer.style.offset.Y, func(screen *ebiten.Image) {
+			g.drawObject(screen, level, obj, &layer.style)
+		})
+	}
+}
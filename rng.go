@@ -0,0 +1,109 @@
+package main
+
+// RandStream - детерминированный генератор (splitmix64). Все состояние -
+// одно число, поэтому его легко сохранить в сейв или реплей.
+type RandStream struct {
+	state uint64
+}
+
+func newRandStream(seed uint64) *RandStream {
+	return &RandStream{state: seed}
+}
+
+// Uint64 возвращает следующее псевдослучайное число
+func (r *RandStream) Uint64() uint64 {
+	r.state += 0x9E3779B97F4A7C15
+	z := r.state
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+// Intn возвращает число в диапазоне [0, n). При n <= 0 возвращает 0.
+func (r *RandStream) Intn(n int) int {
+	if n <= 0 {
+		return 0
+	}
+	return int(r.Uint64() % uint64(n))
+}
+
+// Float64 возвращает число в диапазоне [0, 1)
+func (r *RandStream) Float64() float64 {
+	return float64(r.Uint64()>>11) / (1 << 53)
+}
+
+// Range возвращает число в диапазоне [min, max)
+func (r *RandStream) Range(min, max float64) float64 {
+	return min + r.Float64()*(max-min)
+}
+
+// Chance возвращает true с вероятностью p
+func (r *RandStream) Chance(p float64) bool {
+	return r.Float64() < p
+}
+
+// Константы для разведения потоков от одного зерна
+const (
+	streamLevel uint64 = 0x4C45564C // "LEVL"
+	streamAI    uint64 = 0x41495F5F // "AI__"
+	streamLoot  uint64 = 0x4C4F4F54 // "LOOT"
+)
+
+// RNG - генератор игры с отдельными потоками, чтобы, например, изменения
+// в ИИ не меняли генерацию уровней при том же зерне
+type RNG struct {
+	seed  int64
+	Level *RandStream
+	AI    *RandStream
+	Loot  *RandStream
+}
+
+// RNGState - состояние всех потоков для сейвов и реплеев
+type RNGState struct {
+	Seed  int64  `json:"seed"`
+	Level uint64 `json:"level"`
+	AI    uint64 `json:"ai"`
+	Loot  uint64 `json:"loot"`
+}
+
+func NewRNG(seed int64) *RNG {
+	r := &RNG{seed: seed}
+	r.Reset()
+	return r
+}
+
+// Seed возвращает зерно, из которого созданы потоки
+func (r *RNG) Seed() int64 {
+	return r.seed
+}
+
+// Reset возвращает все потоки в начальное состояние
+func (r *RNG) Reset() {
+	r.Level = newRandStream(deriveSeed(r.seed, streamLevel))
+	r.AI = newRandStream(deriveSeed(r.seed, streamAI))
+	r.Loot = newRandStream(deriveSeed(r.seed, streamLoot))
+}
+
+// State возвращает текущее состояние для сохранения
+func (r *RNG) State() RNGState {
+	return RNGState{
+		Seed:  r.seed,
+		Level: r.Level.state,
+		AI:    r.AI.state,
+		Loot:  r.Loot.state,
+	}
+}
+
+// Restore восстанавливает состояние из сейва или реплея
+func (r *RNG) Restore(s RNGState) {
+	r.seed = s.Seed
+	r.Level = newRandStream(s.Level)
+	r.AI = newRandStream(s.AI)
+	r.Loot = newRandStream(s.Loot)
+}
+
+// deriveSeed перемешивает зерно с номером потока
+func deriveSeed(seed int64, stream uint64) uint64 {
+	mix := newRandStream(uint64(seed) ^ stream)
+	return mix.Uint64()
+}
@@ -0,0 +1,154 @@
+package main
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// Scene - один экран игры (геймплей, меню, пауза и т.д.)
+type Scene interface {
+	Enter(g *Game) // Сцена попала в стек
+	Exit(g *Game)  // Сцена убрана из стека
+	Update(g *Game) error
+	Draw(screen *ebiten.Image, g *Game)
+}
+
+// Overlay - необязательный интерфейс для сцен, под которыми
+// должны рисоваться нижние сцены стека (пауза, диалоги, инвентарь)
+type Overlay interface {
+	IsOverlay() bool
+}
+
+func isOverlay(s Scene) bool {
+	o, ok := s.(Overlay)
+	return ok && o.IsOverlay()
+}
+
+type sceneOp int
+
+const (
+	opPush sceneOp = iota
+	opPop
+	opReplace
+	opReset
+)
+
+type pendingSceneOp struct {
+	op    sceneOp
+	scene Scene
+}
+
+// SceneStack хранит сцены. Обновляется только верхняя сцена, рисуются
+// верхняя и все сцены под ней до первой непрозрачной. Изменения стека,
+// запрошенные во время Update, применяются после него.
+type SceneStack struct {
+	game       *Game
+	scenes     []Scene
+	pending    []pendingSceneOp
+	transition *transitionState
+}
+
+func NewSceneStack(g *Game) *SceneStack {
+	return &SceneStack{game: g}
+}
+
+// Push кладет сцену поверх текущей
+func (s *SceneStack) Push(scene Scene) {
+	s.pending = append(s.pending, pendingSceneOp{opPush, scene})
+}
+
+// Pop убирает верхнюю сцену
+func (s *SceneStack) Pop() {
+	s.pending = append(s.pending, pendingSceneOp{opPop, nil})
+}
+
+// Replace заменяет верхнюю сцену
+func (s *SceneStack) Replace(scene Scene) {
+	s.pending = append(s.pending, pendingSceneOp{opReplace, scene})
+}
+
+// Reset очищает стек и оставляет в нем одну сцену
+func (s *SceneStack) Reset(scene Scene) {
+	s.pending = append(s.pending, pendingSceneOp{opReset, scene})
+}
+
+// Top возвращает верхнюю сцену или nil
+func (s *SceneStack) Top() Scene {
+	if len(s.scenes) == 0 {
+		return nil
+	}
+	return s.scenes[len(s.scenes)-1]
+}
+
+// Len возвращает количество сцен в стеке
+func (s *SceneStack) Len() int {
+	return len(s.scenes)
+}
+
+// Update обновляет верхнюю сцену и применяет отложенные изменения стека
+func (s *SceneStack) Update() error {
+	s.applyPending()
+
+	// Во время перехода ввод заблокирован
+	if s.transition != nil {
+		s.updateTransition()
+		s.applyPending()
+		return nil
+	}
+
+	if top := s.Top(); top != nil {
+		if err := top.Update(s.game); err != nil {
+			return err
+		}
+	}
+
+	s.applyPending()
+	return nil
+}
+
+// Draw рисует видимые сцены снизу вверх
+func (s *SceneStack) Draw(screen *ebiten.Image) {
+	first := len(s.scenes) - 1
+	for first > 0 && isOverlay(s.scenes[first]) {
+		first--
+	}
+	for i := first; i >= 0 && i < len(s.scenes); i++ {
+		s.scenes[i].Draw(screen, s.game)
+	}
+
+	s.drawTransition(screen)
+}
+
+func (s *SceneStack) applyPending() {
+	// Enter/Exit могут запросить новые изменения, поэтому обрабатываем в цикле
+	for len(s.pending) > 0 {
+		op := s.pending[0]
+		s.pending = s.pending[1:]
+
+		switch op.op {
+		case opPush:
+			s.push(op.scene)
+		case opPop:
+			s.pop()
+		case opReplace:
+			s.pop()
+			s.push(op.scene)
+		case opReset:
+			for len(s.scenes) > 0 {
+				s.pop()
+			}
+			s.push(op.scene)
+		}
+	}
+}
+
+func (s *SceneStack) push(scene Scene) {
+	s.scenes = append(s.scenes, scene)
+	scene.Enter(s.game)
+}
+
+func (s *SceneStack) pop() {
+	top := s.Top()
+	if top == nil {
+		return
+	}
+	s.scenes = s.scenes[:len(s.scenes)-1]
+	top.Exit(s.game)
+}
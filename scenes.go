@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+// PlayingScene - основной игровой процесс
+type PlayingScene struct{}
+
+func (s *PlayingScene) Enter(g *Game) {}
+func (s *PlayingScene) Exit(g *Game)  {}
+
+func (s *PlayingScene) Update(g *Game) error {
+	g.updatePlaying()
+	return nil
+}
+
+func (s *PlayingScene) Draw(screen *ebiten.Image, g *Game) {
+	g.drawPlaying(screen)
+}
+
+// GameOverScene - экран проигрыша
+type GameOverScene struct{}
+
+func (s *GameOverScene) Enter(g *Game) {}
+func (s *GameOverScene) Exit(g *Game)  {}
+
+func (s *GameOverScene) Update(g *Game) error {
+	return g.updateGameOver()
+}
+
+func (s *GameOverScene) Draw(screen *ebiten.Image, g *Game) {
+	g.drawGameOver(screen)
+}
+
+// StartupErrorScene показывает ресурсы, которые не загрузились при запуске.
+// Enter - играть с заглушками, Esc - выйти.
+type StartupErrorScene struct {
+	problems []error
+}
+
+func NewStartupErrorScene(problems []error) *StartupErrorScene {
+	return &StartupErrorScene{problems: problems}
+}
+
+func (s *StartupErrorScene) Enter(g *Game) { gameClock.Pause() }
+func (s *StartupErrorScene) Exit(g *Game)  { gameClock.Resume() }
+
+func (s *StartupErrorScene) Update(g *Game) error {
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeySpace):
+		g.scenes.Pop()
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		return ebiten.Termination
+	}
+	return nil
+}
+
+func (s *StartupErrorScene) Draw(screen *ebiten.Image, g *Game) {
+	screen.Fill(color.RGBA{30, 10, 10, 255})
+	face := g.screenManager.fontFace
+	red := color.RGBA{255, 100, 100, 255}
+
+	text.Draw(screen, fmt.Sprintf("%d asset(s) failed to load:", len(s.problems)), face, 20, 30, color.White)
+
+	// Сколько строк помещается между заголовком и подсказкой внизу
+	lines := max((g.display.Height()-100)/20, 1)
+	y := 60
+	for i, err := range s.problems {
+		if i == lines-1 && len(s.problems) > lines {
+			text.Draw(screen, fmt.Sprintf("... and %d more (see the log)", len(s.problems)-i), face, 20, y, red)
+			break
+		}
+		clr := color.Color(color.RGBA{255, 200, 0, 255})
+		if IsRequired(err) {
+			clr = red
+		}
+		text.Draw(screen, err.Error(), face, 20, y, clr)
+		y += 20
+	}
+
+	text.Draw(screen, "Enter: continue with placeholders    Esc: quit", face, 20, g.display.Height()-20, color.White)
+}
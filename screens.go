@@ -11,19 +11,68 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/basicfont"
 )
 
 type ScreenManager struct {
-	debug    bool
-	fontFace font.Face
+	debug        bool
+	fontFace     font.Face
+	rotateSprite bool // Поворачивать спрайт игрока внутри сектора направления
+	leanSprite   bool // Наклонять спрайт игрока при повороте
+	showFacing   bool // Рисовать индикатор направления взгляда
+
+	damagePopups []damagePopup
+
+	// Сообщение триггера message; нулевое messageUntil - пока не сменится
+	message      string
+	messageUntil time.Time
+}
+
+// ShowMessage показывает сообщение на duration игрового времени
+// (0 - пока его не заменит другое)
+func (sm *ScreenManager) ShowMessage(msg string, duration time.Duration) {
+	sm.message = msg
+	sm.messageUntil = time.Time{}
+	if duration > 0 {
+		sm.messageUntil = gameClock.Now().Add(duration)
+	}
+}
+
+// damagePopup - всплывающая цифра урона над игроком
+type damagePopup struct {
+	text  string
+	color color.Color
+	start time.Time
+}
+
+// onDamage - подписчик DamageSystem для HUD
+func (sm *ScreenManager) onDamage(e DamageEvent) {
+	clr := color.Color(color.NRGBA{255, 60, 60, 255})
+	switch e.Source.Type {
+	case DamageFire:
+		clr = color.NRGBA{255, 140, 0, 255}
+	case DamagePoison:
+		clr = color.NRGBA{120, 220, 60, 255}
+	case DamageCold:
+		clr = color.NRGBA{120, 200, 255, 255}
+	}
+
+	sm.damagePopups = append(sm.damagePopups, damagePopup{
+		text:  fmt.Sprintf("-%d", e.Amount),
+		color: clr,
+		start: e.Time,
+	})
 }
 
 func NewScreenManager() *ScreenManager {
 	return &ScreenManager{
-		debug:    true,
-		fontFace: basicfont.Face7x13,
+		debug:        false,
+		fontFace:     basicfont.Face7x13,
+		rotateSprite: false,
+		leanSprite:   true,
+		showFacing:   true,
 	}
 }
 
@@ -32,41 +81,110 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		g.screenManager = NewScreenManager()
 	}
 
-	switch g.gameState {
-	case StateMainMenu:
-		g.drawMainMenu(screen)
-	case StatePlaying:
-		g.drawPlaying(screen)
-	case StateGameOver:
-		g.drawGameOver(screen)
-	default:
-		g.drawDefaultScreen(screen)
+	canvas := g.display.Canvas()
+	g.scenes.Draw(canvas)
+	g.drawHotReloadMessage(canvas)
+	g.display.Present(screen, canvas)
+}
+
+// drawHotReloadMessage показывает результат горячей перезагрузки внизу экрана
+func (g *Game) drawHotReloadMessage(screen *ebiten.Image) {
+	msg, isError := g.hotReload.Message()
+	if msg == "" {
+		return
+	}
+	clr := color.RGBA{120, 255, 120, 255}
+	if isError {
+		clr = color.RGBA{255, 80, 80, 255}
 	}
+	x, y := g.display.Anchor(AnchorBottomLeft, 10, 10)
+	ebitenutil.DrawRect(screen, 0, y-16, float64(g.display.Width()), 22, color.RGBA{0, 0, 0, 180})
+	text.Draw(screen, msg, g.screenManager.fontFace, int(x), int(y), clr)
 }
 
 func (g *Game) drawPlaying(screen *ebiten.Image) {
-	if time.Since(g.player.lastDamageTime) < time.Second/4 {
+	g.updateCamera()
+
+	if gameClock.Since(g.player.lastDamageTime) < time.Second/4 {
 		screen.Fill(color.RGBA{255, 0, 0, 64})
 	} else {
 		screen.Fill(color.RGBA{0xFA, 0xF8, 0xEF, 0xFF})
 	}
 	g.drawWorld(screen)
-	g.drawPlayer(screen)
+	if len(g.levels) > 0 && g.currentLevel < len(g.levels) {
+		g.drawLighting(screen, &g.levels[g.currentLevel])
+	}
 	g.drawUI(screen)
 	g.drawHealthHearts(screen)
 
 	if g.screenManager.debug {
+		g.drawDebugShapes(screen)
 		g.drawDebugInfo(screen)
 	}
 }
 
+// drawDebugShapes обводит коллизии, зоны триггеров и маршруты врагов
+func (g *Game) drawDebugShapes(screen *ebiten.Image) {
+	level := &g.levels[g.currentLevel]
+	for i := range level.Colliders {
+		g.strokeShape(screen, &level.Colliders[i], color.RGBA{255, 60, 60, 255})
+	}
+	for i := range level.triggers {
+		g.strokeShape(screen, &level.triggers[i].Shape, color.RGBA{60, 160, 255, 255})
+	}
+	for _, enemy := range level.Enemies {
+		route := polylineShape(enemy.Patrol.Points, enemy.Patrol.Loop)
+		g.strokeShape(screen, &route, color.RGBA{255, 200, 0, 255})
+	}
+}
+
+// strokeShape рисует контур формы в координатах уровня
+func (g *Game) strokeShape(screen *ebiten.Image, s *Shape, clr color.Color) {
+	if s.Kind == ShapePoint {
+		p := g.camera.ToScreen(s.Points[0])
+		vector.StrokeCircle(screen, float32(p.X), float32(p.Y), 4, 1, clr, true)
+		return
+	}
+	if s.Kind == ShapeEllipse {
+		// Контур эллипса - ломаная по 24 точкам
+		const segments = 24
+		for i := 0; i < segments; i++ {
+			a := s.ellipsePoint(2 * math.Pi * float64(i) / segments)
+			b := s.ellipsePoint(2 * math.Pi * float64(i+1) / segments)
+			g.strokeSegment(screen, a, b, clr)
+		}
+		return
+	}
+	n := len(s.Points)
+	for i := 0; i+1 < n; i++ {
+		g.strokeSegment(screen, s.Points[i], s.Points[i+1], clr)
+	}
+	if s.Kind != ShapePolyline && n > 2 {
+		g.strokeSegment(screen, s.Points[n-1], s.Points[0], clr)
+	}
+}
+
+func (g *Game) strokeSegment(screen *ebiten.Image, a, b Position, clr color.Color) {
+	a, b = g.camera.ToScreen(a), g.camera.ToScreen(b)
+	vector.StrokeLine(screen, float32(a.X), float32(a.Y), float32(b.X), float32(b.Y), 1, clr, true)
+}
+
+// updateCamera наводит камеру на игрока в пределах текущего уровня
+func (g *Game) updateCamera() {
+	worldW, worldH := float64(WinWidth), float64(WinHeight)
+	if len(g.levels) > 0 && g.currentLevel < len(g.levels) {
+		worldW, worldH = g.levels[g.currentLevel].PixelSize()
+	}
+	g.camera.Follow(g.player.Center(), worldW, worldH, g.display.Width(), g.display.Height())
+}
+
 func (g *Game) drawWorld(screen *ebiten.Image) {
 	if len(g.levels) == 0 || g.currentLevel >= len(g.levels) {
 		ebitenutil.DebugPrint(screen, "No levels loaded!")
 		return
 	}
 
-	level := g.levels[g.currentLevel]
+	level := &g.levels[g.currentLevel]
 
 	// Если уровень загружен из Tiled
 	if level.TiledMap != nil {
@@ -80,113 +198,186 @@ func (g *Game) drawWorld(screen *ebiten.Image) {
 		return
 	}
 
-	// Старая отрисовка для сгенерированных уровней
-	for y := 0; y < len(level.Map); y++ {
-		if len(level.Map[y]) == 0 {
-			continue
-		}
-
-		for x := 0; x < len(level.Map[y]); x++ {
+	// Старая отрисовка для сгенерированных уровней, только видимые клетки
+	y0, y1 := max(int(g.camera.Y)/tileSize, 0), min(int(g.camera.Y+g.camera.Height)/tileSize+1, len(level.Map))
+	x0, x1 := max(int(g.camera.X)/tileSize, 0), int(g.camera.X+g.camera.Width)/tileSize+1
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1 && x < len(level.Map[y]); x++ {
 			op := &ebiten.DrawImageOptions{}
 			op.GeoM.Translate(float64(x*tileSize), float64(y*tileSize))
+			g.camera.Apply(op)
 
-			var tileImg *ebiten.Image
-			switch level.Map[y][x] {
-			case TileGrass:
-				tileImg = createColoredRect(color.RGBA{100, 200, 50, 255})
-			case TileWater:
-				tileImg = createColoredRect(color.RGBA{50, 100, 200, 255})
-			case TileTree:
-				tileImg = createColoredRect(color.RGBA{0, 100, 0, 255})
-			default:
-				tileImg = createColoredRect(color.RGBA{200, 200, 200, 255})
+			c, ok := tileColors[level.Map[y][x]]
+			if !ok {
+				c = color.RGBA{200, 200, 200, 255}
 			}
-			screen.DrawImage(tileImg, op)
+			screen.DrawImage(coloredRect(c), op)
 		}
 	}
 
-	g.drawEnemies(screen)
+	g.drawLevelObjects(screen, *level)
+	g.queueEntities(&g.renderQueue, level)
+	g.renderQueue.Flush(screen)
 }
 
-func (g *Game) drawTiledLevel(screen *ebiten.Image, level Level) {
-	if level.TiledMap == nil {
-		return
+// drawLevelObjects рисует выход, закрытые двери и неподобранные ключи
+func (g *Game) drawLevelObjects(screen *ebiten.Image, level Level) {
+	if level.ExitPosition != (Position{}) {
+		pos := g.camera.ToScreen(level.ExitPosition)
+		ebitenutil.DrawRect(screen, pos.X, pos.Y, tileSize, tileSize, color.RGBA{240, 200, 40, 255})
 	}
 
-	// Сначала рисуем тайловые слои
-	for _, layer := range level.TiledMap.Layers {
-		if layer.Type == "tilelayer" && layer.Visible {
-			g.drawTileLayer(screen, level, layer)
+	for _, door := range level.Doors {
+		if door.Open {
+			continue
 		}
+		pos := g.camera.ToScreen(tileTopLeft(door.Tile))
+		ebitenutil.DrawRect(screen, pos.X, pos.Y, tileSize, tileSize, color.RGBA{110, 70, 30, 255})
+		ebitenutil.DrawRect(screen, pos.X+tileSize/2-6, pos.Y+tileSize/2-6, 12, 12, keyColor(door.KeyID))
 	}
 
-	// Затем рисуем объекты
-	for _, layer := range level.TiledMap.Layers {
-		if layer.Type == "objectgroup" && layer.Visible {
-			g.drawObjectLayer(screen, level, layer)
+	for _, key := range level.Keys {
+		if key.Taken {
+			continue
 		}
+		pos := g.camera.ToScreen(key.Position)
+		ebitenutil.DrawRect(screen, pos.X+tileSize/2-8, pos.Y+tileSize/2-4, 16, 8, keyColor(key.ID))
 	}
-}
 
-func (g *Game) drawTileLayer(screen *ebiten.Image, level Level, layer Layer) {
-	for y := 0; y < layer.Height; y++ {
-		for x := 0; x < layer.Width; x++ {
-			idx := x + y*layer.Width
-			if idx >= len(layer.Data) {
-				continue
-			}
+	for _, pickup := range level.Pickups {
+		if pickup.Taken {
+			continue
+		}
+		pos := g.camera.ToScreen(pickup.Position)
+		ebitenutil.DrawRect(screen, pos.X+tileSize/2-6, pos.Y+tileSize/2-6, 12, 12, color.RGBA{220, 30, 60, 255})
+	}
+}
 
-			tileID := layer.Data[idx]
-			if tileID == 0 {
-				continue // Пропускаем пустые тайлы
-			}
+// drawTiledLevel рисует слои по глубине (см. layerDepth): нижние слои,
+// затем отсортированные по Y тайлы, объекты и персонажей, затем верхние
+func (g *Game) drawTiledLevel(screen *ebiten.Image, level *Level) {
+	if level.TiledMap == nil {
+		return
+	}
+	layers := visibleLayers(level.TiledMap.Layers, defaultLayerStyle)
+	for i := range layers {
+		layers[i].index = i
+	}
+	level.tileCache().begin()
 
-			if tileImg, ok := level.TileImages[tileID]; ok {
-				op := &ebiten.DrawImageOptions{}
-				op.GeoM.Translate(
-					float64(x*level.TiledMap.TileWidth),
-					float64(y*level.TiledMap.TileHeight),
-				)
-				screen.DrawImage(tileImg, op)
-			} else {
-				// Отладочная отрисовка для отсутствующих тайлов
-				ebitenutil.DrawRect(
-					screen,
-					float64(x*level.TiledMap.TileWidth),
-					float64(y*level.TiledMap.TileHeight),
-					float64(level.TiledMap.TileWidth),
-					float64(level.TiledMap.TileHeight),
-					color.RGBA{255, 0, 0, 128},
-				)
-			}
+	for _, layer := range layers {
+		if layer.style.depth == depthBelow {
+			g.drawLayer(screen, level, layer)
 		}
 	}
-}
+	g.drawLevelObjects(screen, *level)
 
-func (g *Game) drawObjectLayer(screen *ebiten.Image, level Level, layer Layer) {
-	for _, obj := range layer.Objects {
-		if obj.GID == 0 {
+	q := &g.renderQueue
+	for _, layer := range layers {
+		if layer.style.depth != depthSorted {
 			continue
 		}
+		switch layer.Type {
+		case "tilelayer":
+			g.queueTileLayer(q, level, layer)
+		case "objectgroup":
+			g.queueObjectLayer(q, level, layer)
+		case "imagelayer":
+			// Картинку целиком не отсортировать по Y: рисуем под персонажами
+			g.drawImageLayer(screen, level, layer)
+		}
+	}
+	g.queueEntities(q, level)
+	q.Flush(screen)
 
-		tileImg, ok := level.TileImages[obj.GID]
-		if !ok {
-			log.Printf("Tile with GID %d not found", obj.GID)
-			continue
+	for _, layer := range layers {
+		if layer.style.depth == depthAbove {
+			g.drawLayer(screen, level, layer)
 		}
+	}
+}
 
+func (g *Game) drawLayer(screen *ebiten.Image, level *Level, layer styledLayer) {
+	switch layer.Type {
+	case "tilelayer":
+		g.drawTileLayer(screen, level, layer)
+	case "objectgroup":
+		g.drawObjectLayer(screen, level, layer)
+	case "imagelayer":
+		g.drawImageLayer(screen, level, layer)
+	}
+}
+
+func (g *Game) drawTileLayer(screen *ebiten.Image, level *Level, layer styledLayer) {
+	if g.tileRender == TileRenderCached {
+		g.drawCachedLayer(screen, level, &layer)
+		return
+	}
+	g.forEachVisibleTile(level, &layer, func(x, y, gid int) {
+		g.drawTile(screen, level, gid, x, y, &layer.style)
+	})
+}
+
+// drawTile рисует тайл gid в клетке (x, y) слоя со стилем style
+func (g *Game) drawTile(screen *ebiten.Image, level *Level, gid, x, y int, style *layerStyle) {
+	if tileImg, ok := level.TileImages[gid]; ok {
 		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(
+			float64(x*level.TiledMap.TileWidth),
+			float64(y*level.TiledMap.TileHeight),
+		)
+		style.apply(op, &g.camera)
+		screen.DrawImage(tileImg, op)
+		return
+	}
 
-		// Масштабирование объекта (128x128) к размеру тайла (32x32)
-		scaleX := obj.Width / float64(tileImg.Bounds().Dx())
-		scaleY := obj.Height / float64(tileImg.Bounds().Dy())
-		op.GeoM.Scale(scaleX, scaleY)
+	// Отладочная отрисовка для отсутствующих тайлов
+	shift := style.shift(&g.camera)
+	pos := g.camera.ToScreen(Position{
+		X: float64(x*level.TiledMap.TileWidth) + shift.X,
+		Y: float64(y*level.TiledMap.TileHeight) + shift.Y,
+	})
+	ebitenutil.DrawRect(
+		screen,
+		pos.X,
+		pos.Y,
+		float64(level.TiledMap.TileWidth),
+		float64(level.TiledMap.TileHeight),
+		color.RGBA{255, 0, 0, 128},
+	)
+}
 
-		// Позиционирование с учетом центра объекта
-		op.GeoM.Translate(obj.X, obj.Y)
+func (g *Game) drawObjectLayer(screen *ebiten.Image, level *Level, layer styledLayer) {
+	for _, obj := range layer.Objects {
+		if obj.GID != 0 {
+			g.drawObject(screen, level, obj, &layer.style)
+		}
+	}
+}
 
-		screen.DrawImage(tileImg, op)
+// drawObject рисует тайловый объект Tiled
+func (g *Game) drawObject(screen *ebiten.Image, level *Level, obj Object, style *layerStyle) {
+	tileImg, ok := level.TileImages[obj.GID]
+	if !ok {
+		log.Printf("Tile with GID %d not found", obj.GID)
+		return
 	}
+
+	op := &ebiten.DrawImageOptions{}
+
+	// Масштабирование объекта (128x128) к размеру тайла (32x32)
+	scaleX := obj.Width / float64(tileImg.Bounds().Dx())
+	scaleY := obj.Height / float64(tileImg.Bounds().Dy())
+	op.GeoM.Scale(scaleX, scaleY)
+
+	// У тайловых объектов Tiled (X, Y) - нижний левый угол,
+	// вокруг него же объект поворачивается по часовой стрелке
+	op.GeoM.Translate(0, -obj.Height)
+	op.GeoM.Rotate(obj.Rotation * math.Pi / 180)
+	op.GeoM.Translate(obj.X, obj.Y)
+	style.apply(op, &g.camera)
+
+	screen.DrawImage(tileImg, op)
 }
 
 func (g *Game) isColliding(player *Player, enemy Enemy) bool {
@@ -195,32 +386,40 @@ func (g *Game) isColliding(player *Player, enemy Enemy) bool {
 	return playerRect.Overlaps(enemyRect)
 }
 
-func (g *Game) drawEnemies(screen *ebiten.Image) {
-	if len(g.levels) == 0 || g.currentLevel >= len(g.levels) {
-		return
-	}
+// drawEnemy рисует врага; contact - подсветка касания с игроком (для дебага)
+func (g *Game) drawEnemy(screen *ebiten.Image, enemy Enemy, contact bool) {
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(enemy.Position.X, enemy.Position.Y)
+	g.camera.Apply(op)
+	pos := g.camera.ToScreen(enemy.Position)
 
-	for _, enemy := range g.levels[g.currentLevel].Enemies {
-		// Отрисовка врага
-		op := &ebiten.DrawImageOptions{}
-		op.GeoM.Translate(enemy.Position.X, enemy.Position.Y)
+	if contact {
+		// Подсвечиваем врага при столкновении
+		ebitenutil.DrawRect(screen, pos.X, pos.Y,
+			EnemySpriteWidth, EnemySpriteHeight, color.RGBA{255, 0, 0, 128})
+	}
 
-		// Проверка столкновения (для дебага)
-		if g.player != nil && g.isColliding(g.player, enemy) {
-			// Подсвечиваем врага при столкновении
-			ebitenutil.DrawRect(screen, enemy.Position.X, enemy.Position.Y,
-				EnemySpriteWidth, EnemySpriteHeight, color.RGBA{255, 0, 0, 128})
+	// Рисуем спрайт врага, если он есть. В воде видна только верхняя часть.
+	if enemy.Sprite != nil {
+		screen.DrawImage(submerged(enemy.Sprite, enemy.terrain.Swim), op)
+	} else {
+		// Рисуем красный квадрат как заглушку
+		h := float64(EnemySpriteHeight)
+		if enemy.terrain.Swim {
+			h *= 1 - SwimDepth
 		}
+		ebitenutil.DrawRect(screen, pos.X, pos.Y, EnemySpriteWidth, h, color.RGBA{255, 0, 0, 255})
+	}
+}
 
-		// Рисуем спрайт врага, если он есть
-		if enemy.Sprite != nil {
-			screen.DrawImage(enemy.Sprite, op)
-		} else {
-			// Рисуем красный квадрат как заглушку
-			ebitenutil.DrawRect(screen, enemy.Position.X, enemy.Position.Y,
-				EnemySpriteWidth, EnemySpriteHeight, color.RGBA{255, 0, 0, 255})
-		}
+// submerged обрезает нижнюю часть спрайта, которая под водой
+func submerged(sprite *ebiten.Image, swim bool) *ebiten.Image {
+	if !swim {
+		return sprite
 	}
+	b := sprite.Bounds()
+	b.Max.Y -= int(float64(b.Dy()) * SwimDepth)
+	return sprite.SubImage(b).(*ebiten.Image)
 }
 
 func createColoredRect(clr color.Color) *ebiten.Image {
@@ -230,25 +429,74 @@ func createColoredRect(clr color.Color) *ebiten.Image {
 }
 
 func (g *Game) drawPlayer(screen *ebiten.Image) {
-	if g.player == nil || !g.player.visible {
-		return
-	}
+
+	pos := g.camera.ToScreen(Position{X: g.player.x, Y: g.player.y})
 
 	sprite := g.getCurrentPlayerSprite()
 	if sprite == nil {
-		ebitenutil.DrawRect(screen, g.player.x, g.player.y, 32, 32, color.RGBA{255, 0, 0, 255})
+		ebitenutil.DrawRect(screen, pos.X, pos.Y, 32, 32, color.RGBA{255, 0, 0, 255})
 		return
 	}
 
+	w := float64(sprite.Bounds().Dx())
+	h := float64(sprite.Bounds().Dy())
+
+	// Поворот вокруг центра спрайта
+	rotation := 0.0
+	if g.screenManager.rotateSprite {
+		rotation += g.player.SectorOffset()
+	}
+	if g.screenManager.leanSprite {
+		rotation += g.player.LeanTilt()
+	}
+
 	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-w/2, -h/2)
+	op.GeoM.Rotate(rotation)
 	op.GeoM.Scale(CharScale, CharScale)
-	op.GeoM.Translate(g.player.x, g.player.y)
+	op.GeoM.Translate(pos.X+w*CharScale/2, pos.Y+h*CharScale/2)
 
 	if g.player.invulnerable {
 		op.ColorM.Scale(1, 1, 1, g.player.GetDrawOpacity())
 	}
 
-	screen.DrawImage(sprite, op)
+	screen.DrawImage(submerged(sprite, g.player.terrain.Swim), op)
+
+	if g.screenManager.showFacing {
+		g.drawFacingIndicator(screen, pos.X+w*CharScale/2, pos.Y+h*CharScale/2)
+	}
+}
+
+// drawFacingIndicator рисует стрелку в направлении взгляда игрока
+func (g *Game) drawFacingIndicator(screen *ebiten.Image, cx, cy float64) {
+	rad := g.player.FacingAngle()
+	dx, dy := math.Cos(rad), math.Sin(rad)
+
+	tipX := cx + dx*FacingIndicatorLength
+	tipY := cy + dy*FacingIndicatorLength
+	clr := color.RGBA{255, 220, 0, 255}
+
+	vector.StrokeLine(screen, float32(cx), float32(cy), float32(tipX), float32(tipY), 2, clr, true)
+
+	// Наконечник стрелки
+	const headSize = 6
+	for _, side := range []float64{-1, 1} {
+		hx := tipX - dx*headSize - dy*headSize*side
+		hy := tipY - dy*headSize + dx*headSize*side
+		vector.StrokeLine(screen, float32(tipX), float32(tipY), float32(hx), float32(hy), 2, clr, true)
+	}
+}
+
+// spriteDirection сводит направление взгляда к одному из четырех наборов спрайтов
+func spriteDirection(direction string) string {
+	switch direction {
+	case "forward_right", "back_right":
+		return "right"
+	case "forward_left", "back_left":
+		return "left"
+	default:
+		return direction
+	}
 }
 
 func (g *Game) drawHealthHearts(screen *ebiten.Image) {
@@ -275,15 +523,14 @@ func (g *Game) drawHealthHearts(screen *ebiten.Image) {
 		op := &ebiten.DrawImageOptions{}
 		scale := displayHeartSize / float64(heartFull.Bounds().Dx())
 		op.GeoM.Scale(scale, scale)
-		posX := float64(WinWidth) - rightMargin - float64(totalHearts-1-i)*(displayHeartSize+spacing)
-		posY := topMargin
+		posX, posY := g.display.Anchor(AnchorTopRight, rightMargin+float64(totalHearts-1-i)*(displayHeartSize+spacing), topMargin)
 		op.GeoM.Translate(posX, posY)
 
 		switch {
 		case i >= fullHearts+damagedHearts:
 			continue
 		case i >= fullHearts:
-			timeSinceDamage := time.Since(g.player.lastDamageTime)
+			timeSinceDamage := gameClock.Since(g.player.lastDamageTime)
 			if timeSinceDamage < time.Second {
 				if int(timeSinceDamage.Seconds()*10)%2 == 0 {
 					op.ColorM.Scale(1, 1, 1, 0.5)
@@ -297,23 +544,29 @@ func (g *Game) drawHealthHearts(screen *ebiten.Image) {
 
 	// Индикатор неуязвимости
 	if g.player != nil && g.player.invulnerable {
-		remaining := g.player.invulnDuration.Seconds() - time.Since(g.player.invulnStartTime).Seconds()
+		remaining := g.player.invulnDuration.Seconds() - gameClock.Since(g.player.invulnStartTime).Seconds()
 		invulnText := fmt.Sprintf("Invuln: %.1fs", math.Max(0, remaining))
+		x, y := g.display.Anchor(AnchorTopRight, 150, 30)
 		text.Draw(screen, invulnText, g.screenManager.fontFace,
-			WinWidth-150, 30, color.NRGBA{255, 255, 0, 255})
+			int(x), int(y), color.NRGBA{255, 255, 0, 255})
 	}
 
 	healthText := fmt.Sprintf("%d/%d", g.player.health, g.player.maxHealth)
 	textColor := color.NRGBA{0, 0, 0, 255}
-	textX := int(float64(WinWidth) - rightMargin - float64(totalHearts)*1.5*spacing)
-	textY := int(math.Round(float64(topMargin) / 1.8))
+	x, y := g.display.Anchor(AnchorTopRight, rightMargin+float64(totalHearts)*1.5*spacing, math.Round(topMargin/1.8))
+	textX, textY := int(x), int(y)
 	bigFont := text.FaceWithLineHeight(g.screenManager.fontFace, float64(g.screenManager.fontFace.Metrics().Height*3))
 	text.Draw(screen, healthText, bigFont, textX, textY, textColor)
+
+	if keys := g.player.KeyCount(); keys > 0 {
+		x, y := g.display.Anchor(AnchorTopRight, rightMargin+displayHeartSize, topMargin+displayHeartSize+20)
+		text.Draw(screen, fmt.Sprintf("Keys: %d", keys), g.screenManager.fontFace, int(x), int(y), textColor)
+	}
 }
 
 // Остальные методы остаются без изменений
 // ... (getCurrentPlayerSprite, getRunSprite, getIdleSprite, getAttackSprite)
-// ... (drawUI, drawDebugInfo, drawMainMenu, drawGameOver, drawDefaultScreen)
+// ... (drawUI, drawDebugInfo, drawGameOver)
 // ... (formatFloat, formatInt)
 
 func (g *Game) getCurrentPlayerSprite() *ebiten.Image {
@@ -334,7 +587,7 @@ func (g *Game) getCurrentPlayerSprite() *ebiten.Image {
 
 func (g *Game) getRunSprite() *ebiten.Image {
 	baseIndex := 0
-	switch g.player.direction {
+	switch spriteDirection(g.player.direction) {
 	case "forward":
 		baseIndex = 8 // Индексы 8-11 - бег вперед
 	case "back":
@@ -352,7 +605,7 @@ func (g *Game) getRunSprite() *ebiten.Image {
 }
 
 func (g *Game) getIdleSprite() *ebiten.Image {
-	switch g.player.direction {
+	switch spriteDirection(g.player.direction) {
 	case "forward":
 		return CharacterSprites[1] // Стоя лицом
 	case "back":
@@ -368,7 +621,7 @@ func (g *Game) getIdleSprite() *ebiten.Image {
 
 func (g *Game) getAttackSprite() *ebiten.Image {
 	baseIndex := 20
-	switch g.player.direction {
+	switch spriteDirection(g.player.direction) {
 	case "back":
 		return CharacterSprites[baseIndex+0*4+g.player.attackFrame]
 	case "forward":
@@ -382,24 +635,47 @@ func (g *Game) getAttackSprite() *ebiten.Image {
 }
 
 func (g *Game) drawUI(screen *ebiten.Image) {
-	// Элементы интерфейса
+	// Всплывающий урон поднимается над игроком и исчезает
+	popups := g.screenManager.damagePopups[:0]
+	center := g.camera.ToScreen(g.player.Center())
+	for _, popup := range g.screenManager.damagePopups {
+		elapsed := gameClock.Since(popup.start)
+		if elapsed > DamagePopupDuration {
+			continue
+		}
+		popups = append(popups, popup)
+
+		rise := elapsed.Seconds() * 40
+		text.Draw(screen, popup.text, g.screenManager.fontFace,
+			int(center.X), int(center.Y-20-rise), popup.color)
+	}
+	g.screenManager.damagePopups = popups
+
+	g.drawMessage(screen)
 }
 
-func (g *Game) drawMainMenu(screen *ebiten.Image) {
-	screen.Fill(color.RGBA{0x22, 0x22, 0x44, 0xFF})
-	text.Draw(screen, "ADVENTURE GAME", g.screenManager.fontFace, WinWidth/2-70, WinHeight/2-20, color.White)
-	text.Draw(screen, "Press ENTER to start", g.screenManager.fontFace, WinWidth/2-80, WinHeight/2+20, color.White)
+// drawMessage рисует сообщение триггера внизу по центру экрана
+func (g *Game) drawMessage(screen *ebiten.Image) {
+	sm := g.screenManager
+	if sm.message == "" {
+		return
+	}
+	if !sm.messageUntil.IsZero() && gameClock.Now().After(sm.messageUntil) {
+		sm.message = ""
+		return
+	}
+	bounds := text.BoundString(sm.fontFace, sm.message)
+	w, h := g.display.Width(), g.display.Height()
+	x, y := (w-bounds.Dx())/2, h-80
+	ebitenutil.DrawRect(screen, float64(x-10), float64(y-18), float64(bounds.Dx()+20), 26, color.RGBA{0, 0, 0, 180})
+	text.Draw(screen, sm.message, sm.fontFace, x, y, color.White)
 }
 
 func (g *Game) drawGameOver(screen *ebiten.Image) {
 	screen.Fill(color.RGBA{0x44, 0x22, 0x22, 0xFF})
-	text.Draw(screen, "GAME OVER", g.screenManager.fontFace, WinWidth/2-40, WinHeight/2-20, color.White)
-	text.Draw(screen, "Press R to restart", g.screenManager.fontFace, WinWidth/2-60, WinHeight/2+20, color.White)
-}
-
-func (g *Game) drawDefaultScreen(screen *ebiten.Image) {
-	screen.Fill(color.Black)
-	text.Draw(screen, "UNKNOWN SCREEN STATE", g.screenManager.fontFace, WinWidth/2-100, WinHeight/2, color.White)
+	cx, cy := g.display.Width()/2, g.display.Height()/2
+	text.Draw(screen, "GAME OVER", g.screenManager.fontFace, cx-40, cy-20, color.White)
+	text.Draw(screen, "Press R to restart", g.screenManager.fontFace, cx-60, cy+20, color.White)
 }
 
 func formatFloat(f float64) string {
@@ -411,23 +687,24 @@ func formatInt(i int) string {
 }
 
 func (g *Game) drawDebugInfo(screen *ebiten.Image) {
-	if len(g.levels) == 0 || g.currentLevel >= len(g.levels) {
-		return
-	}
-
-	level := g.levels[g.currentLevel]
-	if level.TiledMap == nil {
-		return
-	}
-
 	debugText := []string{
-		fmt.Sprintf("Layers: %d", len(level.TiledMap.Layers)),
+		fmt.Sprintf("Seed: %d", g.rng.Seed()),
+		"F2: level editor",
 	}
+	hour, minute := g.dayCycle.Clock()
+	debugText = append(debugText, fmt.Sprintf("Time: %02d:%02d", hour, minute))
+
+	if len(g.levels) > 0 && g.currentLevel < len(g.levels) {
+		level := g.levels[g.currentLevel]
+		if level.TiledMap != nil {
+			debugText = append(debugText, fmt.Sprintf("Layers: %d", len(level.TiledMap.Layers)))
 
-	for i, layer := range level.TiledMap.Layers {
-		debugText = append(debugText,
-			fmt.Sprintf("Layer %d: %s (%s, visible: %v)",
-				i, layer.Name, layer.Type, layer.Visible))
+			for i, layer := range level.TiledMap.Layers {
+				debugText = append(debugText,
+					fmt.Sprintf("Layer %d: %s (%s, visible: %v)",
+						i, layer.Name, layer.Type, layer.Visible))
+			}
+		}
 	}
 
 	for i, line := range debugText {
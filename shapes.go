@@ -0,0 +1,258 @@
+package main
+
+import (
+	"image"
+	"math"
+)
+
+// ShapeKind - форма объекта Tiled
+type ShapeKind int
+
+const (
+	ShapeRect ShapeKind = iota
+	ShapeEllipse
+	ShapePolygon
+	ShapePolyline
+	ShapePoint
+)
+
+func (k ShapeKind) String() string {
+	switch k {
+	case ShapeEllipse:
+		return "ellipse"
+	case ShapePolygon:
+		return "polygon"
+	case ShapePolyline:
+		return "polyline"
+	case ShapePoint:
+		return "point"
+	default:
+		return "rectangle"
+	}
+}
+
+// Shape - геометрия объекта в координатах уровня с учетом поворота.
+// У прямоугольника Points - четыре угла, у эллипса - углы описанного
+// прямоугольника, у точки - одна вершина.
+type Shape struct {
+	Kind   ShapeKind
+	Points []Position
+	Bounds image.Rectangle
+
+	// Эллипс: центр, полуоси и поворот в радианах
+	center Position
+	rx, ry float64
+	angle  float64
+}
+
+// objectShape строит форму объекта Tiled. Tiled поворачивает объект по
+// часовой стрелке вокруг (X, Y); у тайловых объектов это нижний левый угол.
+func objectShape(obj Object) Shape {
+	angle := obj.Rotation * math.Pi / 180
+	sin, cos := math.Sincos(angle)
+	toWorld := func(x, y float64) Position {
+		return Position{X: obj.X + x*cos - y*sin, Y: obj.Y + x*sin + y*cos}
+	}
+
+	s := Shape{Kind: ShapeRect, angle: angle}
+	var local []Point
+	switch {
+	case obj.Point:
+		s.Kind = ShapePoint
+		local = []Point{{}}
+	case len(obj.Polygon) > 0:
+		s.Kind = ShapePolygon
+		local = obj.Polygon
+	case len(obj.Polyline) > 0:
+		s.Kind = ShapePolyline
+		local = obj.Polyline
+	default:
+		top := 0.0
+		if obj.GID != 0 {
+			top = -obj.Height
+		}
+		local = []Point{{0, top}, {obj.Width, top}, {obj.Width, top + obj.Height}, {0, top + obj.Height}}
+		if obj.Ellipse {
+			s.Kind = ShapeEllipse
+			s.center = toWorld(obj.Width/2, obj.Height/2)
+			s.rx, s.ry = obj.Width/2, obj.Height/2
+		}
+	}
+
+	s.Points = make([]Position, len(local))
+	for i, p := range local {
+		s.Points[i] = toWorld(p.X, p.Y)
+	}
+	s.Bounds = pointsBounds(s.Points)
+	return s
+}
+
+// rectShape - прямоугольная форма без поворота
+func rectShape(r image.Rectangle) Shape {
+	return Shape{
+		Kind: ShapeRect,
+		Points: []Position{
+			{X: float64(r.Min.X), Y: float64(r.Min.Y)},
+			{X: float64(r.Max.X), Y: float64(r.Min.Y)},
+			{X: float64(r.Max.X), Y: float64(r.Max.Y)},
+			{X: float64(r.Min.X), Y: float64(r.Max.Y)},
+		},
+		Bounds: r,
+	}
+}
+
+// polygonShape - многоугольник по абсолютным вершинам
+func polygonShape(points []Position) Shape {
+	return Shape{Kind: ShapePolygon, Points: points, Bounds: pointsBounds(points)}
+}
+
+// polylineShape - линия по абсолютным точкам; loop замыкает ее в многоугольник
+func polylineShape(points []Position, loop bool) Shape {
+	if loop {
+		return polygonShape(points)
+	}
+	return Shape{Kind: ShapePolyline, Points: points, Bounds: pointsBounds(points)}
+}
+
+func pointsBounds(points []Position) image.Rectangle {
+	if len(points) == 0 {
+		return image.Rectangle{}
+	}
+	minX, minY := points[0].X, points[0].Y
+	maxX, maxY := minX, minY
+	for _, p := range points[1:] {
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+	r := image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+	// Точке и горизонтальной линии нужен хотя бы один пиксель
+	if r.Dx() == 0 {
+		r.Max.X++
+	}
+	if r.Dy() == 0 {
+		r.Max.Y++
+	}
+	return r
+}
+
+// axisAligned сообщает, что прямоугольник не повернут и равен Bounds
+func (s *Shape) axisAligned() bool {
+	return s.Kind == ShapeRect && math.Mod(s.angle, math.Pi/2) == 0
+}
+
+// Contains проверяет, что точка внутри формы. У линий и точек нет площади.
+func (s *Shape) Contains(p Position) bool {
+	switch s.Kind {
+	case ShapeRect, ShapePolygon:
+		return pointInPolygon(p, s.Points)
+	case ShapeEllipse:
+		if s.rx <= 0 || s.ry <= 0 {
+			return false
+		}
+		// Переводим точку в систему координат эллипса
+		sin, cos := math.Sincos(-s.angle)
+		dx, dy := p.X-s.center.X, p.Y-s.center.Y
+		x, y := dx*cos-dy*sin, dx*sin+dy*cos
+		return (x*x)/(s.rx*s.rx)+(y*y)/(s.ry*s.ry) <= 1
+	default:
+		return false
+	}
+}
+
+// ellipsePoint - точка контура эллипса под углом t от его оси
+func (s *Shape) ellipsePoint(t float64) Position {
+	sin, cos := math.Sincos(s.angle)
+	x, y := s.rx*math.Cos(t), s.ry*math.Sin(t)
+	return Position{X: s.center.X + x*cos - y*sin, Y: s.center.Y + x*sin + y*cos}
+}
+
+// Overlaps проверяет пересечение формы с прямоугольником. Для эллипса
+// берется ближайшая к центру точка прямоугольника - это точно для круга
+// и достаточно точно для коллизий с вытянутыми эллипсами.
+func (s *Shape) Overlaps(r image.Rectangle) bool {
+	if !s.Bounds.Overlaps(r) {
+		return false
+	}
+	switch s.Kind {
+	case ShapeRect, ShapePolygon:
+		if s.axisAligned() {
+			return true
+		}
+		if s.Contains(rectCenter(r)) {
+			return true
+		}
+		return s.edgesTouch(r, true)
+	case ShapeEllipse:
+		nearest := Position{
+			X: clampFloat(s.center.X, float64(r.Min.X), float64(r.Max.X)),
+			Y: clampFloat(s.center.Y, float64(r.Min.Y), float64(r.Max.Y)),
+		}
+		return s.Contains(nearest)
+	case ShapePolyline:
+		return s.edgesTouch(r, false)
+	default:
+		return s.Points[0].X >= float64(r.Min.X) && s.Points[0].X < float64(r.Max.X) &&
+			s.Points[0].Y >= float64(r.Min.Y) && s.Points[0].Y < float64(r.Max.Y)
+	}
+}
+
+// edgesTouch проверяет, что ребро формы заходит в прямоугольник.
+// closed - замкнутый контур (последняя вершина соединена с первой).
+func (s *Shape) edgesTouch(r image.Rectangle, closed bool) bool {
+	corners := rectShape(r).Points
+	n := len(s.Points)
+	for i := 0; i < n; i++ {
+		if i == n-1 && !closed {
+			break
+		}
+		a, b := s.Points[i], s.Points[(i+1)%n]
+		if pointInRect(a, r) {
+			return true
+		}
+		for j := range corners {
+			if segmentsIntersect(a, b, corners[j], corners[(j+1)%4]) {
+				return true
+			}
+		}
+	}
+	return n > 0 && pointInRect(s.Points[n-1], r)
+}
+
+func pointInRect(p Position, r image.Rectangle) bool {
+	return p.X >= float64(r.Min.X) && p.X <= float64(r.Max.X) && p.Y >= float64(r.Min.Y) && p.Y <= float64(r.Max.Y)
+}
+
+func rectCenter(r image.Rectangle) Position {
+	return Position{X: float64(r.Min.X+r.Max.X) / 2, Y: float64(r.Min.Y+r.Max.Y) / 2}
+}
+
+// segmentsIntersect проверяет пересечение отрезков ab и cd
+func segmentsIntersect(a, b, c, d Position) bool {
+	cross := func(o, p, q Position) float64 {
+		return (p.X-o.X)*(q.Y-o.Y) - (p.Y-o.Y)*(q.X-o.X)
+	}
+	d1, d2 := cross(c, d, a), cross(c, d, b)
+	d3, d4 := cross(a, b, c), cross(a, b, d)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	// Касания и отрезки на одной прямой
+	onSegment := func(o, p, q Position) bool {
+		return math.Min(o.X, p.X) <= q.X && q.X <= math.Max(o.X, p.X) &&
+			math.Min(o.Y, p.Y) <= q.Y && q.Y <= math.Max(o.Y, p.Y)
+	}
+	return (d1 == 0 && onSegment(c, d, a)) || (d2 == 0 && onSegment(c, d, b)) ||
+		(d3 == 0 && onSegment(a, b, c)) || (d4 == 0 && onSegment(a, b, d))
+}
+
+// pointInPolygon - проверка четности пересечений луча с ребрами
+func pointInPolygon(p Position, poly []Position) bool {
+	inside := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[i], poly[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
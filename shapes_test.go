@@ -0,0 +1,127 @@
+package main
+
+import (
+	"image"
+	"testing"
+)
+
+func TestShapeContainsAndOverlaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		obj      Object
+		kind     ShapeKind
+		inside   []Position
+		outside  []Position
+		overlaps []image.Rectangle
+		misses   []image.Rectangle
+	}{
+		{
+			name:     "rectangle",
+			obj:      Object{X: 0, Y: 0, Width: 100, Height: 50},
+			kind:     ShapeRect,
+			inside:   []Position{{50, 25}, {1, 1}},
+			outside:  []Position{{150, 25}, {50, 60}},
+			overlaps: []image.Rectangle{image.Rect(90, 40, 110, 60)},
+			misses:   []image.Rectangle{image.Rect(200, 200, 210, 210)},
+		},
+		{
+			// Tiled поворачивает по часовой стрелке вокруг (X, Y): полоса
+			// 100x10 ложится по диагонали y = x
+			name:     "rectangle rotated 45 degrees",
+			obj:      Object{X: 0, Y: 0, Width: 100, Height: 10, Rotation: 45},
+			kind:     ShapeRect,
+			inside:   []Position{{31.8, 38.9}, {60, 63}},
+			outside:  []Position{{60, 10}, {10, 60}},
+			overlaps: []image.Rectangle{image.Rect(30, 35, 34, 40)},
+			misses:   []image.Rectangle{image.Rect(55, 5, 65, 15)}, // Внутри Bounds, но мимо полосы
+		},
+		{
+			// У тайлового объекта Y - нижний край
+			name:    "tile object",
+			obj:     Object{GID: 1, X: 0, Y: 32, Width: 32, Height: 32},
+			kind:    ShapeRect,
+			inside:  []Position{{16, 16}},
+			outside: []Position{{16, 40}},
+		},
+		{
+			name:     "ellipse",
+			obj:      Object{X: 0, Y: 0, Width: 100, Height: 50, Ellipse: true},
+			kind:     ShapeEllipse,
+			inside:   []Position{{50, 25}, {95, 25}},
+			outside:  []Position{{5, 5}, {95, 45}},
+			overlaps: []image.Rectangle{image.Rect(45, 0, 55, 5)},
+			misses:   []image.Rectangle{image.Rect(0, 0, 5, 5)}, // Угол описанного прямоугольника
+		},
+		{
+			// После поворота на 90 градусов длинная ось эллипса идет вниз
+			name:    "ellipse rotated 90 degrees",
+			obj:     Object{X: 0, Y: 0, Width: 100, Height: 20, Ellipse: true, Rotation: 90},
+			kind:    ShapeEllipse,
+			inside:  []Position{{-10, 50}, {-10, 90}},
+			outside: []Position{{30, 50}, {-10, -5}},
+		},
+		{
+			name:     "polygon",
+			obj:      Object{X: 10, Y: 10, Polygon: []Point{{0, 0}, {100, 0}, {0, 100}}},
+			kind:     ShapePolygon,
+			inside:   []Position{{20, 20}},
+			outside:  []Position{{90, 90}, {5, 5}},
+			overlaps: []image.Rectangle{image.Rect(50, 50, 70, 70)}, // Гипотенуза проходит через прямоугольник
+			misses:   []image.Rectangle{image.Rect(80, 80, 100, 100)},
+		},
+		{
+			name:     "rotated polygon",
+			obj:      Object{X: 0, Y: 0, Polygon: []Point{{0, 0}, {100, 0}, {0, 100}}, Rotation: 180},
+			kind:     ShapePolygon,
+			inside:   []Position{{-10, -10}},
+			outside:  []Position{{10, 10}},
+			overlaps: []image.Rectangle{image.Rect(-30, -30, -20, -20)},
+			misses:   []image.Rectangle{image.Rect(5, 5, 15, 15)},
+		},
+		{
+			name:     "polyline has no area",
+			obj:      Object{X: 0, Y: 0, Polyline: []Point{{0, 0}, {100, 100}}},
+			kind:     ShapePolyline,
+			outside:  []Position{{50, 50}},
+			overlaps: []image.Rectangle{image.Rect(45, 45, 55, 55)},
+			misses:   []image.Rectangle{image.Rect(80, 0, 90, 10)},
+		},
+		{
+			name:     "point",
+			obj:      Object{X: 10, Y: 10, Point: true},
+			kind:     ShapePoint,
+			outside:  []Position{{10, 10}},
+			overlaps: []image.Rectangle{image.Rect(0, 0, 20, 20)},
+			misses:   []image.Rectangle{image.Rect(11, 0, 20, 20)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := objectShape(tt.obj)
+			if s.Kind != tt.kind {
+				t.Fatalf("kind = %v, want %v", s.Kind, tt.kind)
+			}
+			for _, p := range tt.inside {
+				if !s.Contains(p) {
+					t.Errorf("Contains(%v) = false, want true", p)
+				}
+			}
+			for _, p := range tt.outside {
+				if s.Contains(p) {
+					t.Errorf("Contains(%v) = true, want false", p)
+				}
+			}
+			for _, r := range tt.overlaps {
+				if !s.Overlaps(r) {
+					t.Errorf("Overlaps(%v) = false, want true", r)
+				}
+			}
+			for _, r := range tt.misses {
+				if s.Overlaps(r) {
+					t.Errorf("Overlaps(%v) = true, want false", r)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"image"
+	"math"
+)
+
+type cellKey struct {
+	X, Y int
+}
+
+// SpatialHash - равномерная сетка для быстрого поиска сущностей по области.
+// Сущности идентифицируются целым id (например, индексом врага в Level.Enemies).
+type SpatialHash struct {
+	cellSize int
+	cells    map[cellKey][]int
+	rects    map[int]image.Rectangle
+}
+
+func NewSpatialHash(cellSize int) *SpatialHash {
+	return &SpatialHash{
+		cellSize: cellSize,
+		cells:    make(map[cellKey][]int),
+		rects:    make(map[int]image.Rectangle),
+	}
+}
+
+// cellRange возвращает диапазон клеток, которые покрывает прямоугольник
+func (h *SpatialHash) cellRange(r image.Rectangle) (min, max cellKey) {
+	min = cellKey{floorDiv(r.Min.X, h.cellSize), floorDiv(r.Min.Y, h.cellSize)}
+	max = cellKey{floorDiv(r.Max.X-1, h.cellSize), floorDiv(r.Max.Y-1, h.cellSize)}
+	return min, max
+}
+
+// Insert добавляет сущность с заданным хитбоксом
+func (h *SpatialHash) Insert(id int, r image.Rectangle) {
+	if _, ok := h.rects[id]; ok {
+		h.Update(id, r)
+		return
+	}
+	h.rects[id] = r
+	min, max := h.cellRange(r)
+	for y := min.Y; y <= max.Y; y++ {
+		for x := min.X; x <= max.X; x++ {
+			key := cellKey{x, y}
+			h.cells[key] = append(h.cells[key], id)
+		}
+	}
+}
+
+// Update перемещает сущность. Клетки затрагиваются только если
+// сущность пересекла их границу.
+func (h *SpatialHash) Update(id int, r image.Rectangle) {
+	old, ok := h.rects[id]
+	if !ok {
+		h.Insert(id, r)
+		return
+	}
+
+	oldMin, oldMax := h.cellRange(old)
+	newMin, newMax := h.cellRange(r)
+	h.rects[id] = r
+	if oldMin == newMin && oldMax == newMax {
+		return
+	}
+
+	h.removeFromCells(id, old)
+	for y := newMin.Y; y <= newMax.Y; y++ {
+		for x := newMin.X; x <= newMax.X; x++ {
+			key := cellKey{x, y}
+			h.cells[key] = append(h.cells[key], id)
+		}
+	}
+}
+
+// Remove удаляет сущность из сетки
+func (h *SpatialHash) Remove(id int) {
+	r, ok := h.rects[id]
+	if !ok {
+		return
+	}
+	h.removeFromCells(id, r)
+	delete(h.rects, id)
+}
+
+func (h *SpatialHash) removeFromCells(id int, r image.Rectangle) {
+	min, max := h.cellRange(r)
+	for y := min.Y; y <= max.Y; y++ {
+		for x := min.X; x <= max.X; x++ {
+			key := cellKey{x, y}
+			ids := h.cells[key]
+			for i, other := range ids {
+				if other == id {
+					ids[i] = ids[len(ids)-1]
+					ids = ids[:len(ids)-1]
+					break
+				}
+			}
+			if len(ids) == 0 {
+				delete(h.cells, key)
+			} else {
+				h.cells[key] = ids
+			}
+		}
+	}
+}
+
+// QueryRect возвращает id сущностей, чьи хитбоксы пересекают r
+func (h *SpatialHash) QueryRect(r image.Rectangle) []int {
+	var result []int
+	min, max := h.cellRange(r)
+	single := min == max
+
+	var seen map[int]bool
+	if !single {
+		seen = make(map[int]bool)
+	}
+
+	for y := min.Y; y <= max.Y; y++ {
+		for x := min.X; x <= max.X; x++ {
+			for _, id := range h.cells[cellKey{x, y}] {
+				if !single {
+					if seen[id] {
+						continue
+					}
+					seen[id] = true
+				}
+				if h.rects[id].Overlaps(r) {
+					result = append(result, id)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// QueryRadius возвращает id сущностей, чьи хитбоксы пересекают круг
+func (h *SpatialHash) QueryRadius(center Position, radius float64) []int {
+	bounds := image.Rect(
+		int(math.Floor(center.X-radius)),
+		int(math.Floor(center.Y-radius)),
+		int(math.Ceil(center.X+radius)),
+		int(math.Ceil(center.Y+radius)),
+	)
+
+	var result []int
+	for _, id := range h.QueryRect(bounds) {
+		if rectCircleOverlap(h.rects[id], center, radius) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// Len возвращает количество сущностей в сетке
+func (h *SpatialHash) Len() int {
+	return len(h.rects)
+}
+
+func rectCircleOverlap(r image.Rectangle, c Position, radius float64) bool {
+	nx := clampFloat(c.X, float64(r.Min.X), float64(r.Max.X))
+	ny := clampFloat(c.Y, float64(r.Min.Y), float64(r.Max.Y))
+	return math.Hypot(c.X-nx, c.Y-ny) <= radius
+}
+
+// floorDiv - целочисленное деление с округлением вниз для отрицательных координат
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// Параметры сравнения линейного перебора с пространственным индексом.
+// Один тик - перемещение всех врагов и spatialQueries запросов столкновений
+// (игрок, снаряды, атаки).
+const (
+	spatialWorld   = 4096
+	spatialQueries = 32
+)
+
+var spatialCounts = []int{100, 500, 2000}
+
+// spatialFixture - враги в случайных точках мира, одинаковые для всех запусков
+func spatialFixture(count int) []Enemy {
+	enemies := make([]Enemy, count)
+	rng := rand.New(rand.NewSource(1))
+	for i := range enemies {
+		enemies[i].Position = Position{X: rng.Float64() * spatialWorld, Y: rng.Float64() * spatialWorld}
+	}
+	return enemies
+}
+
+// spatialQuery - хитбокс, сравнимый с хитбоксом игрока
+func spatialQuery(i int) image.Rectangle {
+	x := (i * 97) % spatialWorld
+	y := (i * 61) % spatialWorld
+	return image.Rect(x, y, x+EnemySpriteWidth, y+EnemySpriteHeight)
+}
+
+// spatialMove сдвигает врага, не выпуская его за пределы мира
+func spatialMove(e *Enemy) {
+	e.Position.X = math.Mod(e.Position.X+0.5, spatialWorld)
+}
+
+// linearQuery находит всех врагов, пересекающих r, перебором
+func linearQuery(enemies []Enemy, r image.Rectangle) []int {
+	var result []int
+	for i := range enemies {
+		if r.Overlaps(enemies[i].GetCollisionRect()) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+func TestSpatialHashMatchesLinearScan(t *testing.T) {
+	enemies := spatialFixture(500)
+	hash := NewSpatialHash(SpatialCellSize)
+	for i := range enemies {
+		hash.Insert(i, enemies[i].GetCollisionRect())
+	}
+
+	for tick := 0; tick < 50; tick++ {
+		for i := range enemies {
+			spatialMove(&enemies[i])
+			hash.Update(i, enemies[i].GetCollisionRect())
+		}
+		for q := 0; q < spatialQueries; q++ {
+			r := spatialQuery(tick*spatialQueries + q)
+			want := linearQuery(enemies, r)
+			got := hash.QueryRect(r)
+			sort.Ints(got)
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Fatalf("tick %d, query %v: got %v, want %v", tick, r, got, want)
+			}
+		}
+	}
+}
+
+// spatialHits не дает компилятору выбросить результаты запросов
+var spatialHits int
+
+func BenchmarkLinearScan(b *testing.B) {
+	for _, count := range spatialCounts {
+		b.Run(fmt.Sprintf("enemies=%d", count), func(b *testing.B) {
+			enemies := spatialFixture(count)
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				for i := range enemies {
+					spatialMove(&enemies[i])
+				}
+				for q := 0; q < spatialQueries; q++ {
+					spatialHits += len(linearQuery(enemies, spatialQuery(n*spatialQueries+q)))
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSpatialHash(b *testing.B) {
+	for _, count := range spatialCounts {
+		b.Run(fmt.Sprintf("enemies=%d", count), func(b *testing.B) {
+			enemies := spatialFixture(count)
+			hash := NewSpatialHash(SpatialCellSize)
+			for i := range enemies {
+				hash.Insert(i, enemies[i].GetCollisionRect())
+			}
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				for i := range enemies {
+					spatialMove(&enemies[i])
+					hash.Update(i, enemies[i].GetCollisionRect())
+				}
+				for q := 0; q < spatialQueries; q++ {
+					spatialHits += len(hash.QueryRect(spatialQuery(n*spatialQueries + q)))
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSpatialHashRadius(b *testing.B) {
+	enemies := spatialFixture(2000)
+	hash := NewSpatialHash(SpatialCellSize)
+	for i := range enemies {
+		hash.Insert(i, enemies[i].GetCollisionRect())
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		spatialHits += len(hash.QueryRadius(Position{X: float64(n % spatialWorld), Y: float64(n % spatialWorld)}, 64))
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+)
+
+// Terrain - свойства местности под ногами, общие для игрока и врагов
+type Terrain struct {
+	Speed      float64 // Множитель максимальной скорости
+	Friction   float64 // Множитель трения (меньше - дольше скользить)
+	Footstep   string  // Звук шагов из data/sounds
+	Damage     int     // Урон раз в HazardDamageInterval
+	DamageType DamageType
+	Swim       bool // Плавание: персонаж по пояс в воде
+	Slippery   bool // Лед: разгон и торможение медленнее
+}
+
+var defaultTerrain = Terrain{Speed: 1, Friction: 1}
+
+// Местность по типам тайлов сгенерированных уровней. Tiled может сослаться
+// на них свойством тайла "terrain" (см. terrainFromProperties).
+var tileTerrain = map[TileType]Terrain{
+	TileGrass: {Speed: 1, Friction: 1, Footstep: "step_grass"},
+	TileSand:  {Speed: 0.75, Friction: 1.5, Footstep: "step_sand"},
+	TileStone: {Speed: 1, Friction: 1, Footstep: "step_stone"},
+	TileFloor: {Speed: 1, Friction: 1, Footstep: "step_stone"},
+	TileWater: {Speed: 0.5, Friction: 0.6, Footstep: "swim", Swim: true},
+}
+
+// terrainByName ищет тип тайла по имени из tileTypeNames
+func terrainByName(name string) (Terrain, bool) {
+	for t, n := range tileTypeNames {
+		if n == name {
+			terrain, ok := tileTerrain[t]
+			return terrain, ok
+		}
+	}
+	return Terrain{}, false
+}
+
+// terrainFromProperties собирает местность из свойств тайла Tiled:
+// "terrain" (имя типа тайла как основа), "speed", "friction", "footstep",
+// "damage", "damage_type", "swim" и "slippery". false - свойств местности нет.
+func terrainFromProperties(props []Property) (Terrain, bool) {
+	t, found := defaultTerrain, false
+	if name, ok := propertyString(props, "terrain"); ok {
+		if base, ok := terrainByName(name); ok {
+			t, found = base, true
+		}
+	}
+	if v, ok := propertyFloat(props, "speed"); ok {
+		t.Speed, found = v, true
+	}
+	if v, ok := propertyBool(props, "slippery"); ok {
+		t.Slippery, found = v, true
+		if v {
+			t.Friction = SlipperyFriction
+		}
+	}
+	if v, ok := propertyFloat(props, "friction"); ok {
+		t.Friction, found = v, true
+	}
+	if v, ok := propertyString(props, "footstep"); ok {
+		t.Footstep, found = v, true
+	}
+	if v, ok := propertyFloat(props, "damage"); ok {
+		t.Damage, found = int(v), true
+	}
+	if v, ok := propertyString(props, "damage_type"); ok {
+		t.DamageType, found = parseDamageType(v), true
+	}
+	if v, ok := propertyBool(props, "swim"); ok {
+		t.Swim, found = v, true
+	}
+	return t, found
+}
+
+// TerrainAt возвращает местность в точке pos. В Tiled побеждает верхний
+// непустой слой: свойства его тайла из TSX (или местность по умолчанию),
+// затем свойства самого слоя ("friction", "damage", "damage_type").
+// Мост без свойств над водой - обычная земля.
+func (l *Level) TerrainAt(pos Position) Terrain {
+	if l.TiledMap != nil && l.TiledMap.TileWidth > 0 && l.TiledMap.TileHeight > 0 {
+		x := floorDiv(int(pos.X), l.TiledMap.TileWidth)
+		y := floorDiv(int(pos.Y), l.TiledMap.TileHeight)
+		for _, layer := range l.tileLayersTopDown() {
+			gid := layer.TileAt(x, y)
+			if gid == 0 {
+				continue
+			}
+			t, ok := l.TileTerrain[gid&^tiledFlipFlags]
+			if !ok {
+				t = defaultTerrain
+			}
+			if f, ok := propertyFloat(layer.Properties, "friction"); ok {
+				t.Friction = f
+			}
+			if d, ok := propertyFloat(layer.Properties, "damage"); ok {
+				t.Damage = int(d)
+				dtype, _ := propertyString(layer.Properties, "damage_type")
+				t.DamageType = parseDamageType(dtype)
+			}
+			return t
+		}
+		return defaultTerrain
+	}
+
+	x := floorDiv(int(pos.X), tileSize)
+	y := floorDiv(int(pos.Y), tileSize)
+	if y >= 0 && y < len(l.Map) && x >= 0 && x < len(l.Map[y]) {
+		if t, ok := tileTerrain[l.Map[y][x]]; ok {
+			return t
+		}
+	}
+	return defaultTerrain
+}
+
+// tileTerrains переводит свойства тайлов из TSX в местность по GID
+func tileTerrains(props map[int][]Property) map[int]Terrain {
+	out := make(map[int]Terrain)
+	for gid, p := range props {
+		if t, ok := terrainFromProperties(p); ok {
+			out[gid] = t
+		}
+	}
+	return out
+}
+
+// tsxProperty - свойство тайла в TSX. Значения переводятся в типы JSON,
+// чтобы работали propertyFloat и остальные.
+type tsxProperty struct {
+	Name  string `xml:"name,attr"`
+	Type  string `xml:"type,attr"`
+	Value string `xml:"value,attr"`
+}
+
+func (p tsxProperty) property() Property {
+	prop := Property{Name: p.Name, Type: p.Type, Value: p.Value}
+	switch p.Type {
+	case "int", "float", "object":
+		if v, err := strconv.ParseFloat(p.Value, 64); err == nil {
+			prop.Value = v
+		}
+	case "bool":
+		prop.Value = p.Value == "true"
+	}
+	return prop
+}
+
+// tileLayersTopDown возвращает тайловые слои карты (и из групп) сверху вниз.
+// Список собирается один раз: TerrainAt зовут для каждого персонажа каждый тик.
+func (l *Level) tileLayersTopDown() []*Layer {
+	if l.terrainLayers == nil {
+		walkLayers(l.TiledMap.Layers, func(layer *Layer) {
+			if layer.Type == "tilelayer" {
+				l.terrainLayers = append(l.terrainLayers, layer)
+			}
+		})
+		slices.Reverse(l.terrainLayers)
+	}
+	return l.terrainLayers
+}
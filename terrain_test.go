@@ -0,0 +1,121 @@
+package main
+
+import "testing"
+
+// GID тайлов тестовой карты: вода и песок со свойствами местности,
+// доски моста без свойств
+const (
+	gidWater  = 1
+	gidSand   = 2
+	gidBridge = 3
+)
+
+// terrainTestLevel - карта 4x2: вода внизу, поверх нее мост, песок и лед,
+// а в группе - слой лавы с уроном
+func terrainTestLevel() *Level {
+	m := &TiledMap{
+		Width: 4, Height: 2, TileWidth: 32, TileHeight: 32,
+		Layers: []Layer{
+			{Name: "water", Type: "tilelayer", Width: 4, Height: 2, Data: []int{
+				gidWater, gidWater, gidWater, gidWater,
+				gidWater | 0x80000000, 0, 0, 0,
+			}},
+			{Name: "bridge", Type: "tilelayer", Width: 4, Height: 2, Data: []int{
+				0, gidBridge, gidSand, 0,
+				0, 0, 0, 0,
+			}},
+			{Name: "ice", Type: "tilelayer", Width: 4, Height: 2, Data: []int{
+				0, 0, 0, gidBridge,
+				0, 0, 0, 0,
+			}, Properties: []Property{{Name: "friction", Type: "float", Value: 0.1}}},
+			{Name: "hazards", Type: "group", Layers: []Layer{
+				{Name: "lava", Type: "tilelayer", Width: 4, Height: 2, Data: []int{
+					0, 0, 0, 0,
+					0, gidBridge, 0, 0,
+				}, Properties: []Property{
+					{Name: "damage", Type: "int", Value: 5.0},
+					{Name: "damage_type", Type: "string", Value: "fire"},
+				}},
+			}},
+		},
+	}
+	return &Level{
+		TiledMap: m, Width: 4, Height: 2,
+		TileTerrain: map[int]Terrain{gidWater: tileTerrain[TileWater], gidSand: tileTerrain[TileSand]},
+	}
+}
+
+func TestTerrainAtLayers(t *testing.T) {
+	ice := defaultTerrain
+	ice.Friction = 0.1
+	lava := defaultTerrain
+	lava.Damage, lava.DamageType = 5, DamageFire
+
+	tests := []struct {
+		name string
+		x, y int // Клетка
+		want Terrain
+	}{
+		{"bottom layer", 0, 0, tileTerrain[TileWater]},
+		{"flipped tile keeps its terrain", 0, 1, tileTerrain[TileWater]},
+		{"bridge without properties over water is plain ground", 1, 0, defaultTerrain},
+		{"top tile terrain replaces the one below", 2, 0, tileTerrain[TileSand]},
+		{"layer friction applies to its tiles", 3, 0, ice},
+		{"layer damage inside a group", 1, 1, lava},
+		{"no tiles", 3, 1, defaultTerrain},
+		{"outside the map", -1, 5, defaultTerrain},
+	}
+	l := terrainTestLevel()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos := Position{X: float64(tt.x*32 + 16), Y: float64(tt.y*32 + 16)}
+			if got := l.TerrainAt(pos); got != tt.want {
+				t.Errorf("TerrainAt(%d, %d) = %+v, want %+v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerrainAtFollowsEdits(t *testing.T) {
+	l := terrainTestLevel()
+	at := Position{X: 16, Y: 16}
+	if got := l.TerrainAt(at); !got.Swim {
+		t.Fatalf("TerrainAt = %+v, want water", got)
+	}
+
+	// Правка тайла видна сразу, хотя список слоев уже собран
+	l.TiledMap.Layers[1].SetTileAt(0, 0, gidSand)
+	if got := l.TerrainAt(at); got != tileTerrain[TileSand] {
+		t.Errorf("after placing sand TerrainAt = %+v, want sand", got)
+	}
+
+	// Копия уровня смотрит в свои слои, а не в слои оригинала
+	c := l.clone()
+	c.TiledMap.Layers[1].SetTileAt(0, 0, 0)
+	if got := c.TerrainAt(at); !got.Swim {
+		t.Errorf("clone after removing sand TerrainAt = %+v, want water", got)
+	}
+	if got := l.TerrainAt(at); got != tileTerrain[TileSand] {
+		t.Errorf("original after editing the clone TerrainAt = %+v, want sand", got)
+	}
+}
+
+func TestTerrainAtGeneratedLevel(t *testing.T) {
+	l := &Level{Map: [][]TileType{{TileGrass, TileSand}, {TileWater, TileTree}}}
+	tests := []struct {
+		x, y int
+		want Terrain
+	}{
+		{0, 0, tileTerrain[TileGrass]},
+		{1, 0, tileTerrain[TileSand]},
+		{0, 1, tileTerrain[TileWater]},
+		{1, 1, defaultTerrain}, // У деревьев нет своей местности
+		{2, 0, defaultTerrain},
+	}
+	for _, tt := range tests {
+		pos := Position{X: float64(tt.x*tileSize + 1), Y: float64(tt.y*tileSize + 1)}
+		if got := l.TerrainAt(pos); got != tt.want {
+			t.Errorf("TerrainAt(%d, %d) = %+v, want %+v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
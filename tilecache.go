@@ -0,0 +1,180 @@
+package main
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// TileRenderMode - способ отрисовки тайловых слоев
+type TileRenderMode int
+
+const (
+	TileRenderCached TileRenderMode = iota // Слои заранее собраны в картинки кусков
+	TileRenderCulled                       // По тайлу, только видимые
+	TileRenderAll                          // По тайлу, весь слой (для сравнения в бенчмарке)
+)
+
+func (m TileRenderMode) String() string {
+	switch m {
+	case TileRenderCulled:
+		return "culled"
+	case TileRenderAll:
+		return "all tiles"
+	default:
+		return "cached"
+	}
+}
+
+type tileCacheKey struct {
+	layer int // styledLayer.index
+	chunk TilePoint
+}
+
+type tileCacheEntry struct {
+	img  *ebiten.Image // nil - в куске нет тайлов
+	used int           // Кадр, в котором кусок последний раз рисовался
+}
+
+// TileCache хранит тайловые слои, собранные в картинки по TileCacheChunk
+// клеток. Кусок собирается при первом появлении на экране и выбрасывается,
+// если его долго не видно.
+type TileCache struct {
+	entries map[tileCacheKey]*tileCacheEntry
+	frame   int
+	pad     image.Point // Насколько тайлы тайлсета больше клетки карты
+}
+
+func newTileCache(level *Level) *TileCache {
+	c := &TileCache{entries: make(map[tileCacheKey]*tileCacheEntry)}
+	tw, th := level.TileSize()
+	for _, img := range level.TileImages {
+		c.pad.X = max(c.pad.X, img.Bounds().Dx()-tw)
+		c.pad.Y = max(c.pad.Y, img.Bounds().Dy()-th)
+	}
+	return c
+}
+
+// begin начинает кадр и раз в TileCacheSweepFrames выбрасывает старые куски
+func (c *TileCache) begin() {
+	c.frame++
+	if c.frame%TileCacheSweepFrames != 0 {
+		return
+	}
+	for key, e := range c.entries {
+		if c.frame-e.used > TileCacheKeepFrames {
+			c.drop(key, e)
+		}
+	}
+}
+
+func (c *TileCache) drop(key tileCacheKey, e *tileCacheEntry) {
+	if e.img != nil {
+		e.img.Deallocate()
+	}
+	delete(c.entries, key)
+}
+
+// tileCache возвращает кэш уровня, создавая его при первом обращении
+func (l *Level) tileCache() *TileCache {
+	if l.tiles == nil {
+		l.tiles = newTileCache(l)
+	}
+	return l.tiles
+}
+
+// InvalidateTiles сбрасывает кэш после смены тайлсетов
+func (l *Level) InvalidateTiles() {
+	if l.tiles == nil {
+		return
+	}
+	for key, e := range l.tiles.entries {
+		l.tiles.drop(key, e)
+	}
+	l.tiles = nil
+}
+
+// InvalidateTile пересобирает куски всех слоев с клеткой (x, y)
+func (l *Level) InvalidateTile(x, y int) {
+	if l.tiles == nil {
+		return
+	}
+	chunk := TilePoint{X: floorDiv(x, TileCacheChunk), Y: floorDiv(y, TileCacheChunk)}
+	for key, e := range l.tiles.entries {
+		if key.chunk == chunk {
+			l.tiles.drop(key, e)
+		}
+	}
+}
+
+// drawCachedLayer рисует видимые куски слоя из кэша
+func (g *Game) drawCachedLayer(screen *ebiten.Image, level *Level, layer *styledLayer) {
+	cache := level.tileCache()
+	tw, th := level.TileSize()
+
+	view := g.visibleTiles(level, &layer.style).Intersect(image.Rect(0, 0, layer.Width, layer.Height))
+	if view.Empty() {
+		return
+	}
+
+	for cy := floorDiv(view.Min.Y, TileCacheChunk); cy <= floorDiv(view.Max.Y-1, TileCacheChunk); cy++ {
+		for cx := floorDiv(view.Min.X, TileCacheChunk); cx <= floorDiv(view.Max.X-1, TileCacheChunk); cx++ {
+			key := tileCacheKey{layer: layer.index, chunk: TilePoint{X: cx, Y: cy}}
+			e, ok := cache.entries[key]
+			if !ok {
+				e = &tileCacheEntry{img: renderTileChunk(level, &layer.Layer, key.chunk, cache.pad)}
+				cache.entries[key] = e
+			}
+			e.used = cache.frame
+			if e.img == nil {
+				continue
+			}
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(float64(cx*TileCacheChunk*tw), float64(cy*TileCacheChunk*th))
+			layer.style.apply(op, &g.camera)
+			screen.DrawImage(e.img, op)
+		}
+	}
+}
+
+// renderTileChunk собирает кусок слоя в одну картинку. Возвращает nil,
+// если в куске нет ни одного тайла.
+func renderTileChunk(level *Level, layer *Layer, chunk TilePoint, pad image.Point) *ebiten.Image {
+	tw, th := level.TileSize()
+	var img *ebiten.Image
+	for ty := 0; ty < TileCacheChunk; ty++ {
+		for tx := 0; tx < TileCacheChunk; tx++ {
+			gid := layer.TileAt(chunk.X*TileCacheChunk+tx, chunk.Y*TileCacheChunk+ty)
+			if gid == 0 {
+				continue
+			}
+			if img == nil {
+				img = ebiten.NewImage(TileCacheChunk*tw+pad.X, TileCacheChunk*th+pad.Y)
+			}
+			x, y := float64(tx*tw), float64(ty*th)
+			if tileImg, ok := level.TileImages[gid]; ok {
+				op := &ebiten.DrawImageOptions{}
+				op.GeoM.Translate(x, y)
+				img.DrawImage(tileImg, op)
+				continue
+			}
+			// Отладочная отрисовка для отсутствующих тайлов
+			ebitenutil.DrawRect(img, x, y, float64(tw), float64(th), color.RGBA{255, 0, 0, 128})
+		}
+	}
+	return img
+}
+
+// Картинки-заливки для сгенерированных уровней, по одной на цвет
+var coloredRects = map[color.RGBA]*ebiten.Image{}
+
+func coloredRect(c color.RGBA) *ebiten.Image {
+	img, ok := coloredRects[c]
+	if !ok {
+		img = createColoredRect(c)
+		coloredRects[c] = img
+	}
+	return img
+}
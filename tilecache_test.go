@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Цена drawTiledLevel на CPU: go test -bench TileRender. Без игрового
+// цикла команды отрисовки только собираются, поэтому время кадра
+// замеряет go run . bench tiles.
+func benchmarkTileRender(b *testing.B, mode TileRenderMode) {
+	g := &Game{tileRender: mode}
+	level := benchTileLevel(benchMapSize, benchTileSize)
+	screen := ebiten.NewImage(benchScreenW, benchScreenH)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		benchCamera(g, n)
+		g.drawTiledLevel(screen, level)
+	}
+}
+
+func BenchmarkTileRenderAll(b *testing.B)    { benchmarkTileRender(b, TileRenderAll) }
+func BenchmarkTileRenderCulled(b *testing.B) { benchmarkTileRender(b, TileRenderCulled) }
+func BenchmarkTileRenderCached(b *testing.B) { benchmarkTileRender(b, TileRenderCached) }
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Имена файлов тайлсета, который пишется рядом с картой
+const (
+	terrainTilesetFile = "terrain.tsx"
+	terrainImageFile   = "terrain.png"
+)
+
+// Порядок тайлов в terrain.png: GID = TileType + 1
+var terrainTiles = []TileType{TileGrass, TileWater, TileTree, TileStone, TileSand, TileWall, TileFloor}
+
+// tiledMapFile - TiledMap с обязательными для редактора полями заголовка
+type tiledMapFile struct {
+	*TiledMap
+	Orientation  string `json:"orientation"`
+	RenderOrder  string `json:"renderorder"`
+	Type         string `json:"type"`
+	Version      string `json:"version"`
+	NextObjectID int    `json:"nextobjectid"`
+}
+
+// tiledMapFromLevel переводит сгенерированный уровень в формат Tiled:
+// слой местности, скрытый слой коллизий и слой объектов
+func tiledMapFromLevel(l *Level) *TiledMap {
+	w, h := l.Width, l.Height
+	terrain := make([]int, w*h)
+	collision := make([]int, w*h)
+	for y := 0; y < h && y < len(l.Map); y++ {
+		for x := 0; x < w && x < len(l.Map[y]); x++ {
+			t := l.Map[y][x]
+			terrain[y*w+x] = int(t) + 1
+			if !isWalkableTile(t) {
+				collision[y*w+x] = int(t) + 1
+			}
+		}
+	}
+
+	objects := levelObjects(l, 1)
+	objects = append(objects, lightObjects(l, len(objects)+1)...)
+
+	m := &TiledMap{
+		Width:      w,
+		Height:     h,
+		TileWidth:  tileSize,
+		TileHeight: tileSize,
+		Tilesets:   []TilesetRef{{FirstGID: 1, Source: terrainTilesetFile}},
+		Layers: []Layer{
+			{Name: "terrain", Type: "tilelayer", Data: terrain, Width: w, Height: h, Opacity: 1, Visible: true},
+			{Name: "collision", Type: "tilelayer", Data: collision, Width: w, Height: h, Opacity: 1},
+			{Name: "objects", Type: "objectgroup", Objects: objects, Opacity: 1, Visible: true},
+		},
+	}
+	if l.Dark {
+		m.Properties = []Property{{Name: "dark", Type: "bool", Value: true}}
+	}
+	return m
+}
+
+// lightObjects переводит источники света сгенерированного уровня в точки
+// "torch" (см. newObjectLight)
+func lightObjects(l *Level, firstID int) []Object {
+	var objects []Object
+	for i, light := range l.Lights {
+		objects = append(objects, Object{
+			Id:    firstID + i,
+			X:     light.Position.X,
+			Y:     light.Position.Y,
+			Type:  "torch",
+			Name:  "torch",
+			Point: true,
+			Properties: []Property{
+				{Name: "radius", Type: "float", Value: light.Radius},
+				{Name: "flicker", Type: "bool", Value: light.Flicker},
+			},
+		})
+	}
+	return objects
+}
+
+// Типы объектов Tiled, которые игра превращает в данные уровня.
+// Выход - триггер со своей формой и свойствами, поэтому сохраняется как есть.
+var gameObjectTypes = map[string]bool{
+	"enemy":        true,
+	"player_start": true,
+	"door":         true,
+	"key":          true,
+}
+
+// levelObjects собирает объекты Tiled из данных уровня, нумеруя их с firstID
+func levelObjects(l *Level, firstID int) []Object {
+	var objects []Object
+	add := func(typ, name string, pos Position, props []Property) {
+		objects = append(objects, gameObject(l, firstID+len(objects), typ, name, pos, props))
+	}
+
+	add("player_start", "start", l.StartPosition, nil)
+	if l.ExitPosition != (Position{}) && !l.hasTrigger("level_exit") {
+		add("level_exit", "exit", l.ExitPosition, nil)
+	}
+	for _, e := range l.Enemies {
+		add("enemy", e.Type, e.Position, e.properties())
+	}
+	tw, th := l.TileSize()
+	for _, d := range l.Doors {
+		pos := Position{X: float64(d.Tile.X * tw), Y: float64(d.Tile.Y * th)}
+		add("door", "door", pos, []Property{{Name: "key_id", Type: "int", Value: d.KeyID}})
+	}
+	for _, k := range l.Keys {
+		if !k.Taken {
+			add("key", "key", k.Position, keyProperties(k))
+		}
+	}
+	return objects
+}
+
+// gameObject - объект Tiled размером в клетку уровня
+func gameObject(l *Level, id int, typ, name string, pos Position, props []Property) Object {
+	tw, th := l.TileSize()
+	return Object{
+		Id:         id,
+		X:          pos.X,
+		Y:          pos.Y,
+		Width:      float64(tw),
+		Height:     float64(th),
+		Type:       typ,
+		Name:       name,
+		Properties: props,
+	}
+}
+
+func keyProperties(k Key) []Property {
+	return []Property{{Name: "key_id", Type: "int", Value: k.ID}}
+}
+
+// tiledMapForSave возвращает карту для сохранения уровня. У карт из Tiled
+// игровые объекты обновляются на своих местах по id: остаются в своих
+// слоях и сохраняют остальные поля и свойства. Удаленные в редакторе
+// объекты пропадают, новые попадают в слой, где уже лежат объекты того же
+// типа, или в первый слой объектов.
+func tiledMapForSave(l *Level) *TiledMap {
+	if l.TiledMap == nil {
+		return tiledMapFromLevel(l)
+	}
+
+	m := *l.TiledMap
+	m.Layers = cloneLayers(l.TiledMap.Layers)
+
+	enemies := make(map[int]*Enemy)
+	for i := range l.Enemies {
+		if id := l.Enemies[i].ObjectID; id != 0 {
+			enemies[id] = &l.Enemies[i]
+		}
+	}
+	keys := make(map[int]*Key)
+	for i := range l.Keys {
+		if id := l.Keys[i].ObjectID; id != 0 && !l.Keys[i].Taken {
+			keys[id] = &l.Keys[i]
+		}
+	}
+	saved := make(map[int]bool)
+
+	nextID := 1
+	homes := make(map[string]*Layer) // Слой для новых объектов по типу
+	var firstGroup, start *Layer
+	startIndex := -1
+	walkLayers(m.Layers, func(layer *Layer) {
+		if layer.Type != "objectgroup" {
+			return
+		}
+		if firstGroup == nil {
+			firstGroup = layer
+		}
+		objects := layer.Objects[:0]
+		for _, obj := range layer.Objects {
+			nextID = max(nextID, obj.Id+1)
+			switch obj.Type {
+			case "enemy":
+				e, ok := enemies[obj.Id]
+				if !ok {
+					continue
+				}
+				obj.X, obj.Y, obj.Name = e.Position.X, e.Position.Y, e.Type
+				obj.Properties = mergeProperties(obj.Properties, e.properties(), "health", "speed", "damage", "patrol")
+			case "key":
+				k, ok := keys[obj.Id]
+				if !ok {
+					continue
+				}
+				obj.X, obj.Y = k.Position.X, k.Position.Y
+				obj.Properties = mergeProperties(obj.Properties, keyProperties(*k), "key_id")
+			case "player_start":
+				// Игра берет последнюю стартовую точку, ее и двигаем
+				start, startIndex = layer, len(objects)
+			}
+			if gameObjectTypes[obj.Type] && homes[obj.Type] == nil {
+				homes[obj.Type] = layer
+			}
+			saved[obj.Id] = true
+			objects = append(objects, obj)
+		}
+		layer.Objects = objects
+	})
+
+	if firstGroup == nil {
+		m.Layers = append(m.Layers, Layer{Name: "objects", Type: "objectgroup", Opacity: 1, Visible: true})
+		firstGroup = &m.Layers[len(m.Layers)-1]
+	}
+	add := func(typ, name string, pos Position, props []Property) {
+		home := homes[typ]
+		if home == nil {
+			home = firstGroup
+		}
+		home.Objects = append(home.Objects, gameObject(l, nextID, typ, name, pos, props))
+		nextID++
+	}
+
+	if start != nil {
+		start.Objects[startIndex].X, start.Objects[startIndex].Y = l.StartPosition.X, l.StartPosition.Y
+	} else {
+		add("player_start", "start", l.StartPosition, nil)
+	}
+	if l.ExitPosition != (Position{}) && !l.hasTrigger("level_exit") {
+		add("level_exit", "exit", l.ExitPosition, nil)
+	}
+	for _, e := range l.Enemies {
+		if e.ObjectID == 0 || !saved[e.ObjectID] {
+			add("enemy", e.Type, e.Position, e.properties())
+		}
+	}
+	tw, th := l.TileSize()
+	for _, d := range l.Doors {
+		if d.ObjectID == 0 || !saved[d.ObjectID] {
+			pos := Position{X: float64(d.Tile.X * tw), Y: float64(d.Tile.Y * th)}
+			add("door", "door", pos, []Property{{Name: "key_id", Type: "int", Value: d.KeyID}})
+		}
+	}
+	for _, k := range l.Keys {
+		if !k.Taken && (k.ObjectID == 0 || !saved[k.ObjectID]) {
+			add("key", "key", k.Position, keyProperties(k))
+		}
+	}
+	return &m
+}
+
+// mergeProperties заменяет в props свойства с именами names на fresh,
+// остальные свойства автора остаются как были
+func mergeProperties(props, fresh []Property, names ...string) []Property {
+	out := make([]Property, 0, len(props)+len(fresh))
+	for _, p := range props {
+		replaced := false
+		for _, name := range names {
+			if p.Name == name {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			out = append(out, p)
+		}
+	}
+	return append(out, fresh...)
+}
+
+// saveTiledMap записывает карту в JSON и тайлсет местности рядом с ней
+func saveTiledMap(m *TiledMap, path string) error {
+	if err := writeTiledMap(m, path); err != nil {
+		return err
+	}
+	return writeTerrainTileset(filepath.Dir(path))
+}
+
+// writeTiledMap записывает карту в JSON с заголовком, который ожидает Tiled
+func writeTiledMap(m *TiledMap, path string) error {
+	file := tiledMapFile{
+		TiledMap:     m,
+		Orientation:  "orthogonal",
+		RenderOrder:  "right-down",
+		Type:         "map",
+		Version:      "1.10",
+		NextObjectID: 1,
+	}
+	for _, layer := range m.AllLayers() {
+		for _, obj := range layer.Objects {
+			file.NextObjectID = max(file.NextObjectID, obj.Id+1)
+		}
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode map: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write map: %v", err)
+	}
+	return nil
+}
+
+// writeTerrainTileset создает terrain.tsx и terrain.png с цветами тайлов
+func writeTerrainTileset(dir string) error {
+	img := image.NewRGBA(image.Rect(0, 0, tileSize*len(terrainTiles), tileSize))
+	for i, t := range terrainTiles {
+		c := tileColors[t]
+		for y := 0; y < tileSize; y++ {
+			for x := i * tileSize; x < (i+1)*tileSize; x++ {
+				img.Set(x, y, c)
+			}
+		}
+	}
+
+	f, err := os.Create(filepath.Join(dir, terrainImageFile))
+	if err != nil {
+		return fmt.Errorf("failed to create tileset image: %v", err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to encode tileset image: %v", err)
+	}
+	// Ошибка Close означает, что картинка могла не записаться целиком
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write tileset image: %v", err)
+	}
+
+	// Свойство "terrain" сохраняет местность тайла (см. terrainFromProperties)
+	var tiles strings.Builder
+	for i, t := range terrainTiles {
+		if _, ok := tileTerrain[t]; ok {
+			fmt.Fprintf(&tiles, " <tile id=\"%d\">\n  <properties>\n   <property name=\"terrain\" value=\"%s\"/>\n  </properties>\n </tile>\n", i, t)
+		}
+	}
+
+	tsx := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" name="terrain" tilewidth="%d" tileheight="%d" tilecount="%d" columns="%d">
+ <image source="%s" width="%d" height="%d"/>
+%s</tileset>
+`, tileSize, tileSize, len(terrainTiles), len(terrainTiles), terrainImageFile, img.Rect.Dx(), img.Rect.Dy(), tiles.String())
+
+	if err := os.WriteFile(filepath.Join(dir, terrainTilesetFile), []byte(tsx), 0o644); err != nil {
+		return fmt.Errorf("failed to write tileset: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// TransitionKind - вид перехода между сценами
+type TransitionKind int
+
+const (
+	TransitionFade      TransitionKind = iota // Затемнение в цвет и обратно
+	TransitionCrossfade                       // Старый кадр растворяется поверх нового
+	TransitionWipe                            // Шторка слева направо
+	TransitionIris                            // Сужающийся круг вокруг точки
+)
+
+// Transition описывает анимацию смены сцены или уровня.
+// Смена выполняется в середине перехода (для Crossfade - в начале).
+type Transition struct {
+	Kind       TransitionKind
+	Duration   time.Duration
+	Color      color.RGBA
+	Focus      func() Position // Центр диафрагмы, nil - центр экрана
+	OnComplete func()
+}
+
+func FadeTransition(clr color.RGBA) Transition {
+	return Transition{Kind: TransitionFade, Duration: TransitionDuration, Color: clr}
+}
+
+func CrossfadeTransition() Transition {
+	return Transition{Kind: TransitionCrossfade, Duration: TransitionDuration}
+}
+
+func WipeTransition(clr color.RGBA) Transition {
+	return Transition{Kind: TransitionWipe, Duration: TransitionDuration, Color: clr}
+}
+
+func IrisTransition(clr color.RGBA, focus func() Position) Transition {
+	return Transition{Kind: TransitionIris, Duration: TransitionDuration, Color: clr, Focus: focus}
+}
+
+type transitionState struct {
+	Transition
+	change   func()
+	start    time.Time
+	changed  bool
+	snapshot *ebiten.Image // Последний кадр старой сцены для Crossfade
+	captured bool
+}
+
+// Пиксель белого цвета для DrawTriangles
+var whitePixel *ebiten.Image
+
+func getWhitePixel() *ebiten.Image {
+	if whitePixel == nil {
+		img := ebiten.NewImage(3, 3)
+		img.Fill(color.White)
+		whitePixel = img.SubImage(image.Rect(1, 1, 2, 2)).(*ebiten.Image)
+	}
+	return whitePixel
+}
+
+// Transition запускает переход: change вызывается, когда экран закрыт.
+// Пока переход идет, сцены не получают ввод. Повторный запрос во время
+// перехода игнорируется.
+func (s *SceneStack) Transition(t Transition, change func()) {
+	if s.transition != nil {
+		return
+	}
+	if t.Duration <= 0 {
+		change()
+		if t.OnComplete != nil {
+			t.OnComplete()
+		}
+		return
+	}
+	s.transition = &transitionState{
+		Transition: t,
+		change:     change,
+		start:      gameClock.Wall(),
+	}
+}
+
+// InTransition сообщает, идет ли сейчас переход
+func (s *SceneStack) InTransition() bool {
+	return s.transition != nil
+}
+
+// progress возвращает долю пройденного перехода от 0 до 1
+func (ts *transitionState) progress() float64 {
+	return clampFloat(gameClock.Wall().Sub(ts.start).Seconds()/ts.Duration.Seconds(), 0, 1)
+}
+
+// coverage - насколько экран закрыт (0 - открыт, 1 - полностью закрыт)
+func (ts *transitionState) coverage() float64 {
+	p := ts.progress()
+	if p < 0.5 {
+		return p * 2
+	}
+	return (1 - p) * 2
+}
+
+func (s *SceneStack) updateTransition() {
+	ts := s.transition
+
+	if ts.Kind == TransitionCrossfade {
+		// Ждем, пока Draw сохранит кадр старой сцены
+		if !ts.captured {
+			ts.start = gameClock.Wall()
+			return
+		}
+		if !ts.changed {
+			ts.changed = true
+			ts.change()
+		}
+	} else if !ts.changed && ts.progress() >= 0.5 {
+		ts.changed = true
+		ts.change()
+	}
+
+	if ts.progress() >= 1 {
+		s.transition = nil
+		if ts.OnComplete != nil {
+			ts.OnComplete()
+		}
+	}
+}
+
+func (s *SceneStack) drawTransition(screen *ebiten.Image) {
+	ts := s.transition
+	if ts == nil {
+		return
+	}
+
+	w := float64(screen.Bounds().Dx())
+	h := float64(screen.Bounds().Dy())
+
+	switch ts.Kind {
+	case TransitionCrossfade:
+		if !ts.captured {
+			if ts.snapshot == nil {
+				ts.snapshot = ebiten.NewImage(screen.Bounds().Dx(), screen.Bounds().Dy())
+			}
+			ts.snapshot.DrawImage(screen, nil)
+			ts.captured = true
+			return
+		}
+		op := &ebiten.DrawImageOptions{}
+		op.ColorScale.ScaleAlpha(float32(1 - ts.progress()))
+		screen.DrawImage(ts.snapshot, op)
+
+	case TransitionFade:
+		c := ts.Color
+		alpha := ts.coverage()
+		vector.DrawFilledRect(screen, 0, 0, float32(w), float32(h),
+			color.RGBA{
+				uint8(float64(c.R) * alpha),
+				uint8(float64(c.G) * alpha),
+				uint8(float64(c.B) * alpha),
+				uint8(255 * alpha),
+			}, false)
+
+	case TransitionWipe:
+		// Шторка заезжает слева и уезжает вправо
+		p := ts.progress()
+		if p < 0.5 {
+			vector.DrawFilledRect(screen, 0, 0, float32(w*p*2), float32(h), ts.Color, false)
+		} else {
+			x := float32(w * (p - 0.5) * 2)
+			vector.DrawFilledRect(screen, x, 0, float32(w)-x, float32(h), ts.Color, false)
+		}
+
+	case TransitionIris:
+		center := Position{X: w / 2, Y: h / 2}
+		if ts.Focus != nil {
+			center = ts.Focus()
+		}
+		// Радиус, при котором круг открывает весь экран из любой точки
+		maxRadius := math.Hypot(w, h)
+		radius := maxRadius * (1 - ts.coverage())
+		drawIris(screen, center, radius, ts.Color)
+	}
+}
+
+// drawIris закрашивает экран цветом clr, оставляя круглое окно
+func drawIris(screen *ebiten.Image, center Position, radius float64, clr color.RGBA) {
+	w := float32(screen.Bounds().Dx())
+	h := float32(screen.Bounds().Dy())
+
+	var path vector.Path
+	path.MoveTo(0, 0)
+	path.LineTo(w, 0)
+	path.LineTo(w, h)
+	path.LineTo(0, h)
+	path.Close()
+	if radius > 0 {
+		path.MoveTo(float32(center.X+radius), float32(center.Y))
+		path.Arc(float32(center.X), float32(center.Y), float32(radius), 0, 2*math.Pi, vector.Clockwise)
+		path.Close()
+	}
+
+	vs, is := path.AppendVerticesAndIndicesForFilling(nil, nil)
+	for i := range vs {
+		vs[i].SrcX, vs[i].SrcY = 1, 1
+		vs[i].ColorR = float32(clr.R) / 255
+		vs[i].ColorG = float32(clr.G) / 255
+		vs[i].ColorB = float32(clr.B) / 255
+		vs[i].ColorA = float32(clr.A) / 255
+	}
+
+	op := &ebiten.DrawTrianglesOptions{FillRule: ebiten.FillRuleEvenOdd}
+	screen.DrawTriangles(vs, is, getWhitePixel(), op)
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"log"
+	"time"
+)
+
+// TriggerEvent - что произошло между игроком и зоной триггера
+type TriggerEvent int
+
+const (
+	TriggerEnter TriggerEvent = iota // Игрок вошел в зону
+	TriggerStay                      // Игрок остается в зоне (каждый тик)
+	TriggerExit                      // Игрок вышел из зоны
+)
+
+func (e TriggerEvent) String() string {
+	switch e {
+	case TriggerEnter:
+		return "enter"
+	case TriggerStay:
+		return "stay"
+	default:
+		return "exit"
+	}
+}
+
+// Trigger - зона на уровне, которая реагирует на игрока. Создается из
+// объектов Tiled любой формы; свойства берутся из Object.Properties.
+type Trigger struct {
+	ID         int
+	Type       string // teleport, door, damage_zone, message, level_exit
+	Name       string
+	Shape      Shape
+	Properties []Property
+	Door       int  // Индекс в Level.Doors для триггера двери
+	Once       bool // Срабатывает только при первом посещении
+	spent      bool
+	inside     bool
+}
+
+// triggerHandlers - реакции на события по типу триггера
+var triggerHandlers = map[string]func(g *Game, t *Trigger, e TriggerEvent){
+	"teleport":    onTeleport,
+	"door":        onDoor,
+	"damage_zone": onDamageZone,
+	"message":     onMessage,
+	"level_exit":  onLevelExit,
+}
+
+// isTriggerType сообщает, что объект Tiled этого типа становится триггером
+func isTriggerType(typ string) bool {
+	_, ok := triggerHandlers[typ]
+	return ok
+}
+
+// newObjectTrigger создает триггер из объекта Tiled
+func newObjectTrigger(obj Object) Trigger {
+	t := Trigger{
+		ID:         obj.Id,
+		Type:       obj.Type,
+		Name:       obj.Name,
+		Shape:      objectShape(obj),
+		Properties: obj.Properties,
+		Door:       -1,
+	}
+	t.Once, _ = propertyBool(obj.Properties, "once")
+	return t
+}
+
+// Contains проверяет, что игрок в зоне. Прямоугольники, линии и точки
+// срабатывают от касания хитбокса, многоугольники и эллипсы - когда
+// внутрь зашел центр игрока.
+func (t *Trigger) Contains(rect image.Rectangle, center Position) bool {
+	switch t.Shape.Kind {
+	case ShapePolygon, ShapeEllipse:
+		return t.Shape.Contains(center)
+	default:
+		return t.Shape.Overlaps(rect)
+	}
+}
+
+// buildTriggers добавляет к триггерам из карты зоны дверей и выхода
+// уровней без объектов Tiled (сгенерированных)
+func (l *Level) buildTriggers() {
+	l.triggers = append(l.triggers[:0], l.Triggers...)
+
+	tw, th := l.TileSize()
+	for i, d := range l.Doors {
+		r := image.Rect(d.Tile.X*tw, d.Tile.Y*th, (d.Tile.X+1)*tw, (d.Tile.Y+1)*th)
+		l.triggers = append(l.triggers, Trigger{
+			Type:  "door",
+			Shape: rectShape(r.Inset(-DoorReach)),
+			Door:  i,
+		})
+	}
+
+	if l.ExitPosition == (Position{}) || l.hasTrigger("level_exit") {
+		return
+	}
+	x, y := int(l.ExitPosition.X), int(l.ExitPosition.Y)
+	// Выход срабатывает, когда в клетку зашел центр игрока, а не край
+	l.triggers = append(l.triggers, Trigger{
+		Type:  "level_exit",
+		Shape: polygonShape(rectShape(image.Rect(x, y, x+tw, y+th)).Points),
+		Door:  -1,
+	})
+}
+
+// hasTrigger сообщает, что в карте есть триггер этого типа
+func (l *Level) hasTrigger(typ string) bool {
+	for _, t := range l.Triggers {
+		if t.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// resetTriggers забывает, в каких зонах был игрок (при входе на уровень)
+func (l *Level) resetTriggers() {
+	for i := range l.triggers {
+		l.triggers[i].setInside(false)
+	}
+}
+
+// setInside меняет положение игрока относительно зоны без событий.
+// Одноразовая зона, которую покинули без TriggerExit, тоже считается
+// отработавшей, иначе она сработает при следующем входе.
+func (t *Trigger) setInside(inside bool) {
+	if t.Once && t.inside && !inside {
+		t.spent = true
+	}
+	t.inside = inside
+}
+
+// settleTriggers отмечает зоны, в которых игрок уже стоит, без событий.
+// Нужно после телепорта, чтобы игрок не прыгнул обратно.
+func (g *Game) settleTriggers() {
+	level := &g.levels[g.currentLevel]
+	rect, center := g.player.GetCollisionRect(), g.player.Center()
+	for i := range level.triggers {
+		level.triggers[i].setInside(level.triggers[i].Contains(rect, center))
+	}
+}
+
+// checkTriggers рассылает события входа, пребывания и выхода
+func (g *Game) checkTriggers() {
+	level := &g.levels[g.currentLevel]
+	for i := range level.triggers {
+		t := &level.triggers[i]
+		// Обработчик мог переместить игрока, поэтому берем позицию заново
+		inside := t.Contains(g.player.GetCollisionRect(), g.player.Center())
+
+		var event TriggerEvent
+		switch {
+		case inside && !t.inside:
+			event = TriggerEnter
+		case inside:
+			event = TriggerStay
+		case t.inside:
+			event = TriggerExit
+		default:
+			continue
+		}
+		t.inside = inside
+
+		if t.spent {
+			continue
+		}
+		if handler, ok := triggerHandlers[t.Type]; ok {
+			handler(g, t, event)
+		}
+		if t.Once && event == TriggerExit {
+			t.spent = true
+		}
+	}
+}
+
+// onTeleport переносит игрока к объекту с именем из свойства "target"
+// или в точку из свойств "x" и "y"
+func onTeleport(g *Game, t *Trigger, e TriggerEvent) {
+	if e != TriggerEnter {
+		return
+	}
+	dest, ok := g.teleportTarget(t)
+	if !ok {
+		log.Printf("Teleport #%d %q has no valid target", t.ID, t.Name)
+		return
+	}
+	g.player.x, g.player.y = dest.X, dest.Y
+	g.player.body.Stop()
+	g.player.SetPath(nil)
+	g.sounds.Play("teleport")
+	g.settleTriggers()
+}
+
+func (g *Game) teleportTarget(t *Trigger) (Position, bool) {
+	if name, ok := propertyString(t.Properties, "target"); ok {
+		level := g.levels[g.currentLevel]
+		if level.TiledMap == nil {
+			return Position{}, false
+		}
+		for _, layer := range level.TiledMap.AllLayers() {
+			for _, obj := range layer.Objects {
+				if obj.Name == name && obj.Id != t.ID {
+					return Position{X: obj.X, Y: obj.Y}, true
+				}
+			}
+		}
+		return Position{}, false
+	}
+	x, okX := propertyFloat(t.Properties, "x")
+	y, okY := propertyFloat(t.Properties, "y")
+	return Position{X: x, Y: y}, okX && okY
+}
+
+// onDoor открывает запертую дверь, если у игрока есть ключ
+func onDoor(g *Game, t *Trigger, e TriggerEvent) {
+	level := &g.levels[g.currentLevel]
+	if e == TriggerExit || t.Door < 0 || t.Door >= len(level.Doors) || level.Doors[t.Door].Open {
+		return
+	}
+	if g.player.UseKey(level.Doors[t.Door].KeyID) {
+		level.OpenDoor(t.Door)
+		g.sounds.Play("door")
+	}
+}
+
+// onDamageZone наносит урон из свойства "damage" раз в "interval" секунд,
+// пока игрок в зоне. Тип урона - свойство "damage_type".
+func onDamageZone(g *Game, t *Trigger, e TriggerEvent) {
+	if e == TriggerExit {
+		return
+	}
+	amount, _ := propertyFloat(t.Properties, "damage")
+	dtype, _ := propertyString(t.Properties, "damage_type")
+	interval := HazardDamageInterval
+	if s, ok := propertyFloat(t.Properties, "interval"); ok {
+		interval = time.Duration(s * float64(time.Second))
+	}
+	g.damage.Apply(g.player, DamageSource{
+		Kind:                  SourceZone,
+		ID:                    t.ID,
+		Amount:                int(amount),
+		Type:                  parseDamageType(dtype),
+		Cooldown:              interval,
+		IgnoreInvulnerability: true,
+	})
+}
+
+// onMessage показывает текст из свойства "text" при входе в зону.
+// Сообщение держится "duration" секунд после выхода.
+func onMessage(g *Game, t *Trigger, e TriggerEvent) {
+	msg, ok := propertyString(t.Properties, "text")
+	if !ok {
+		msg = t.Name
+	}
+	switch e {
+	case TriggerEnter:
+		g.screenManager.ShowMessage(msg, 0)
+	case TriggerExit:
+		duration := TriggerMessageDuration
+		if s, ok := propertyFloat(t.Properties, "duration"); ok {
+			duration = time.Duration(s * float64(time.Second))
+		}
+		g.screenManager.ShowMessage(msg, duration)
+	}
+}
+
+// onLevelExit переводит на уровень из свойства "level" или на следующий
+func onLevelExit(g *Game, t *Trigger, e TriggerEvent) {
+	if e != TriggerEnter {
+		return
+	}
+	next := g.currentLevel + 1
+	if v, ok := propertyFloat(t.Properties, "level"); ok {
+		next = int(v)
+	}
+	if next == g.currentLevel || next < 0 || next >= len(g.levels) {
+		return
+	}
+	g.ChangeLevel(next, WipeTransition(color.RGBA{0, 0, 0, 255}))
+}
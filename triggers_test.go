@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"testing"
+)
+
+// Зона для тестов триггеров и позиции игрока внутри нее и снаружи
+var (
+	testTriggerZone = image.Rect(1000, 1000, 1200, 1200)
+	testInside      = Position{X: 1050, Y: 1050}
+	testOutside     = Position{X: 100, Y: 100}
+)
+
+// triggerGame - игра с одним уровнем и одной зоной типа "test", обработчик
+// которой записывает события в events
+func triggerGame(t *testing.T, once bool, events *[]TriggerEvent) *Game {
+	t.Helper()
+	triggerHandlers["test"] = func(g *Game, tr *Trigger, e TriggerEvent) {
+		*events = append(*events, e)
+	}
+	t.Cleanup(func() { delete(triggerHandlers, "test") })
+
+	level := Level{triggers: []Trigger{{Type: "test", Shape: rectShape(testTriggerZone), Door: -1, Once: once}}}
+	return &Game{levels: []Level{level}, player: NewPlayer()}
+}
+
+func TestCheckTriggers(t *testing.T) {
+	tests := []struct {
+		name  string
+		once  bool
+		moves []Position // Позиция игрока на каждом тике
+		want  []TriggerEvent
+	}{
+		{
+			name:  "enter, stay and exit",
+			moves: []Position{testOutside, testInside, testInside, testOutside, testOutside},
+			want:  []TriggerEvent{TriggerEnter, TriggerStay, TriggerExit},
+		},
+		{
+			name:  "zone fires again on the next visit",
+			moves: []Position{testInside, testOutside, testInside},
+			want:  []TriggerEvent{TriggerEnter, TriggerExit, TriggerEnter},
+		},
+		{
+			name:  "once fires only on the first visit",
+			once:  true,
+			moves: []Position{testInside, testInside, testOutside, testInside, testOutside},
+			want:  []TriggerEvent{TriggerEnter, TriggerStay, TriggerExit},
+		},
+		{
+			name:  "nothing happens outside",
+			moves: []Position{testOutside, testOutside},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []TriggerEvent
+			g := triggerGame(t, tt.once, &events)
+			for _, pos := range tt.moves {
+				g.player.x, g.player.y = pos.X, pos.Y
+				g.checkTriggers()
+			}
+			if fmt.Sprint(events) != fmt.Sprint(tt.want) {
+				t.Errorf("events = %v, want %v", events, tt.want)
+			}
+		})
+	}
+}
+
+func TestOnceTriggerSpentWithoutExit(t *testing.T) {
+	var events []TriggerEvent
+	g := triggerGame(t, true, &events)
+	g.player.x, g.player.y = testInside.X, testInside.Y
+	g.checkTriggers()
+
+	// Игрок покинул уровень, стоя в зоне: события выхода не было, но
+	// одноразовая зона все равно отработала
+	g.levels[0].resetTriggers()
+	g.checkTriggers()
+	if fmt.Sprint(events) != fmt.Sprint([]TriggerEvent{TriggerEnter}) {
+		t.Errorf("events = %v, want [enter]", events)
+	}
+
+	// settleTriggers отмечает зону без событий
+	var settled []TriggerEvent
+	g = triggerGame(t, false, &settled)
+	g.player.x, g.player.y = testInside.X, testInside.Y
+	g.settleTriggers()
+	g.checkTriggers()
+	if fmt.Sprint(settled) != fmt.Sprint([]TriggerEvent{TriggerStay}) {
+		t.Errorf("events after settleTriggers = %v, want [stay]", settled)
+	}
+}
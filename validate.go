@@ -0,0 +1,347 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Severity - насколько серьезна найденная проблема
+type Severity int
+
+const (
+	SeverityWarning Severity = iota // Игра запустится, но что-то будет не так
+	SeverityError                   // Карта сломана или загрузится не той
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// ValidationIssue - одна проблема в файле карты или тайлсета
+type ValidationIssue struct {
+	File     string
+	Severity Severity
+	Message  string
+}
+
+func (i ValidationIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.File, i.Severity, i.Message)
+}
+
+// Старшие биты GID в Tiled - флаги отражения и поворота
+const tiledFlipFlags = 0xF0000000
+
+// gidRange - диапазон GID одного тайлсета [first, first+count)
+type gidRange struct {
+	first, count int
+}
+
+// mapValidator собирает проблемы одной карты
+type mapValidator struct {
+	path   string
+	issues []ValidationIssue
+	gids   []gidRange
+	broken bool // Какой-то тайлсет не прочитан: GID не проверяем, чтобы не дублировать ошибку
+}
+
+func (v *mapValidator) add(file string, sev Severity, format string, args ...interface{}) {
+	v.issues = append(v.issues, ValidationIssue{File: file, Severity: sev, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *mapValidator) errorf(format string, args ...interface{}) {
+	v.add(v.path, SeverityError, format, args...)
+}
+
+func (v *mapValidator) warnf(format string, args ...interface{}) {
+	v.add(v.path, SeverityWarning, format, args...)
+}
+
+// ValidateMap проверяет карту Tiled и ее тайлсеты, не загружая их в игру
+func ValidateMap(path string) []ValidationIssue {
+	v := &mapValidator{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		v.errorf("cannot read map: %v", err)
+		return v.issues
+	}
+	var m TiledMap
+	if err := json.Unmarshal(data, &m); err != nil {
+		v.errorf("invalid JSON: %v", err)
+		return v.issues
+	}
+
+	if !m.Infinite && (m.Width <= 0 || m.Height <= 0) {
+		v.errorf("invalid map size %dx%d", m.Width, m.Height)
+	}
+	if m.TileWidth <= 0 || m.TileHeight <= 0 {
+		v.errorf("invalid tile size %dx%d", m.TileWidth, m.TileHeight)
+		return v.issues
+	}
+
+	for _, ts := range m.Tilesets {
+		v.checkTileset(ts)
+	}
+	for _, layer := range m.AllLayers() {
+		switch layer.Type {
+		case "tilelayer":
+			v.checkTileLayer(&m, layer)
+		case "objectgroup":
+			v.checkObjects(&m, layer)
+		case "imagelayer":
+			v.checkImageLayer(layer)
+		}
+	}
+	v.checkGameObjects(&m)
+	return v.issues
+}
+
+// checkTileset проверяет TSX и его изображение и запоминает диапазон GID
+func (v *mapValidator) checkTileset(ts TilesetRef) {
+	if !v.readTileset(ts) {
+		v.broken = true
+	}
+}
+
+func (v *mapValidator) readTileset(ts TilesetRef) bool {
+	if ts.Source == "" {
+		v.errorf("embedded tileset at firstgid %d is not supported, export it to TSX", ts.FirstGID)
+		return false
+	}
+
+	tsxPath := filepath.Join(filepath.Dir(v.path), ts.Source)
+	f, err := os.Open(tsxPath)
+	if err != nil {
+		v.errorf("missing tileset %s: %v", ts.Source, err)
+		return false
+	}
+	defer f.Close()
+
+	var tsx struct {
+		TileWidth  int `xml:"tilewidth,attr"`
+		TileHeight int `xml:"tileheight,attr"`
+		Image      struct {
+			Source string `xml:"source,attr"`
+			Width  int    `xml:"width,attr"`
+			Height int    `xml:"height,attr"`
+		} `xml:"image"`
+	}
+	if err := xml.NewDecoder(f).Decode(&tsx); err != nil {
+		v.add(tsxPath, SeverityError, "invalid TSX: %v", err)
+		return false
+	}
+
+	// Нулевой размер тайла дал бы деление на ноль при подсчете колонок
+	if tsx.TileWidth <= 0 || tsx.TileHeight <= 0 {
+		v.add(tsxPath, SeverityError, "invalid tile size %dx%d", tsx.TileWidth, tsx.TileHeight)
+		return false
+	}
+	if tsx.Image.Source == "" {
+		v.add(tsxPath, SeverityError, "tileset has no image (image collections are not supported)")
+		return false
+	}
+	if tsx.Image.Width <= 0 || tsx.Image.Height <= 0 {
+		v.add(tsxPath, SeverityError, "image %s has no width/height attributes", tsx.Image.Source)
+		return false
+	}
+
+	imgPath := filepath.Join(filepath.Dir(tsxPath), tsx.Image.Source)
+	if cfg, err := decodeImageConfig(imgPath); err != nil {
+		v.add(tsxPath, SeverityError, "cannot load image %s: %v", tsx.Image.Source, err)
+		return false
+	} else if cfg.Width != tsx.Image.Width || cfg.Height != tsx.Image.Height {
+		v.add(tsxPath, SeverityWarning, "image %s is %dx%d, but the TSX says %dx%d",
+			tsx.Image.Source, cfg.Width, cfg.Height, tsx.Image.Width, tsx.Image.Height)
+	}
+
+	count := (tsx.Image.Width / tsx.TileWidth) * (tsx.Image.Height / tsx.TileHeight)
+	if count == 0 {
+		v.add(tsxPath, SeverityError, "image %s is smaller than one tile", tsx.Image.Source)
+		return false
+	}
+	v.gids = append(v.gids, gidRange{first: ts.FirstGID, count: count})
+	return true
+}
+
+func decodeImageConfig(path string) (image.Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return image.Config{}, err
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	return cfg, err
+}
+
+// knownGID проверяет, что GID попадает в один из тайлсетов
+func (v *mapValidator) knownGID(gid int) bool {
+	if v.broken {
+		return true
+	}
+	gid &^= tiledFlipFlags
+	for _, r := range v.gids {
+		if gid >= r.first && gid < r.first+r.count {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *mapValidator) checkTileLayer(m *TiledMap, layer Layer) {
+	if m.Infinite {
+		// Размер бесконечной карты задают куски, проверяем только их
+		for _, c := range layer.Chunks {
+			if len(c.Data) != c.Width*c.Height {
+				v.errorf("layer %q chunk (%d,%d) has %d tiles, expected %d (compressed data is not supported)",
+					layer.Name, c.X, c.Y, len(c.Data), c.Width*c.Height)
+			}
+		}
+		if len(layer.Data) > 0 {
+			v.warnf("layer %q of an infinite map has data outside chunks, it is ignored", layer.Name)
+		}
+	} else {
+		if layer.Width != m.Width || layer.Height != m.Height {
+			v.warnf("layer %q is %dx%d, map is %dx%d", layer.Name, layer.Width, layer.Height, m.Width, m.Height)
+		}
+		if len(layer.Data) != layer.Width*layer.Height {
+			v.errorf("layer %q has %d tiles, expected %d (compressed data is not supported)",
+				layer.Name, len(layer.Data), layer.Width*layer.Height)
+		}
+	}
+
+	var missing []int
+	counts, flipped := map[int]int{}, 0
+	layer.forEachTile(func(_, _, gid int) {
+		if gid&tiledFlipFlags != 0 {
+			flipped++
+		}
+		if !v.knownGID(gid) {
+			gid &^= tiledFlipFlags
+			if counts[gid] == 0 {
+				missing = append(missing, gid)
+			}
+			counts[gid]++
+		}
+	})
+	for _, gid := range missing {
+		v.errorf("layer %q uses GID %d (%d tiles) that no tileset provides", layer.Name, gid, counts[gid])
+	}
+	if flipped > 0 {
+		v.warnf("layer %q has %d flipped or rotated tiles, they are drawn as missing", layer.Name, flipped)
+	}
+}
+
+func (v *mapValidator) checkImageLayer(layer Layer) {
+	if layer.Image == "" {
+		v.warnf("image layer %q has no image", layer.Name)
+		return
+	}
+	if _, err := decodeImageConfig(filepath.Join(filepath.Dir(v.path), layer.Image)); err != nil {
+		v.errorf("image layer %q: cannot load image %s: %v", layer.Name, layer.Image, err)
+	}
+}
+
+func (v *mapValidator) checkObjects(m *TiledMap, layer Layer) {
+	mapW := float64(m.Width * m.TileWidth)
+	mapH := float64(m.Height * m.TileHeight)
+
+	for _, obj := range layer.Objects {
+		name := objectLabel(obj)
+		if obj.GID != 0 && !v.knownGID(obj.GID) {
+			v.errorf("object %s in layer %q uses GID %d that no tileset provides", name, layer.Name, obj.GID&^tiledFlipFlags)
+		}
+
+		// У тайловых объектов Tiled точка привязки - нижний левый угол
+		top := obj.Y
+		if obj.GID != 0 {
+			top -= obj.Height
+		}
+		// У бесконечной карты нет границ: объекты могут стоять и в минусе
+		if !m.Infinite && (obj.X < 0 || top < 0 || obj.X+obj.Width > mapW || top+obj.Height > mapH) {
+			v.errorf("object %s in layer %q at (%.0f, %.0f) is outside the %.0fx%.0f map",
+				name, layer.Name, obj.X, obj.Y, mapW, mapH)
+		}
+
+		if obj.Type == "enemy" && !IsKnownEnemyType(obj.Name) {
+			v.errorf("object %s has unknown enemy type %q (known: %v)", name, obj.Name, EnemyTypeNames())
+		}
+	}
+}
+
+// checkGameObjects проверяет объекты, без которых уровень не играется
+func (v *mapValidator) checkGameObjects(m *TiledMap) {
+	starts := 0
+	doorKeys, keys := map[int]bool{}, map[int]bool{}
+	for _, layer := range m.AllLayers() {
+		for _, obj := range layer.Objects {
+			id, _ := propertyFloat(obj.Properties, "key_id")
+			switch obj.Type {
+			case "player_start":
+				starts++
+			case "door":
+				doorKeys[int(id)] = true
+			case "key":
+				keys[int(id)] = true
+			}
+		}
+	}
+
+	switch {
+	case starts == 0:
+		v.errorf("no player_start object")
+	case starts > 1:
+		v.warnf("%d player_start objects, the last one is used", starts)
+	}
+	for id := range doorKeys {
+		if !keys[id] {
+			v.warnf("door with key_id %d has no matching key", id)
+		}
+	}
+}
+
+func objectLabel(obj Object) string {
+	if obj.Name != "" {
+		return fmt.Sprintf("#%d %q", obj.Id, obj.Name)
+	}
+	return fmt.Sprintf("#%d", obj.Id)
+}
+
+// runValidate проверяет переданные карты или все карты из LevelManifest.
+// Возвращает 1, если найдена хотя бы одна ошибка.
+func runValidate(args []string, out io.Writer) int {
+	paths := args
+	if len(paths) == 0 {
+		paths = LevelManifest
+	}
+
+	errors, warnings := 0, 0
+	for _, path := range paths {
+		issues := ValidateMap(path)
+		for _, issue := range issues {
+			fmt.Fprintln(out, issue)
+			if issue.Severity == SeverityError {
+				errors++
+			} else {
+				warnings++
+			}
+		}
+		if len(issues) == 0 {
+			fmt.Fprintf(out, "%s: ok\n", path)
+		}
+	}
+
+	fmt.Fprintf(out, "%d map(s) checked: %d error(s), %d warning(s)\n", len(paths), errors, warnings)
+	if errors > 0 {
+		return 1
+	}
+	return 0
+}
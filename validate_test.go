@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeValidTileset пишет в dir тайлсет 2x1 тайла 32x32 (GID 1 и 2)
+func writeValidTileset(t *testing.T, dir string) {
+	t.Helper()
+	f, err := os.Create(filepath.Join(dir, "tiles.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, image.NewRGBA(image.Rect(0, 0, 64, 32))); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	tsx := `<?xml version="1.0" encoding="UTF-8"?>
+<tileset name="tiles" tilewidth="32" tileheight="32" tilecount="2" columns="2">
+ <image source="tiles.png" width="64" height="32"/>
+</tileset>`
+	if err := os.WriteFile(filepath.Join(dir, "tiles.tsx"), []byte(tsx), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// validTestMap - карта 2x2, которая проходит проверку без ошибок
+func validTestMap() TiledMap {
+	return TiledMap{
+		Width: 2, Height: 2, TileWidth: 32, TileHeight: 32,
+		Tilesets: []TilesetRef{{FirstGID: 1, Source: "tiles.tsx"}},
+		Layers: []Layer{
+			{Name: "ground", Type: "tilelayer", Width: 2, Height: 2, Data: []int{1, 2, 2, 1}, Opacity: 1, Visible: true},
+			{Name: "objects", Type: "objectgroup", Opacity: 1, Visible: true, Objects: []Object{
+				{Id: 1, Type: "player_start", X: 16, Y: 16},
+			}},
+		},
+	}
+}
+
+func TestValidateMap(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(m *TiledMap)
+		errors []string // Подстроки ожидаемых ошибок; пусто - ошибок нет
+	}{
+		{
+			name:   "valid",
+			modify: func(m *TiledMap) {},
+		},
+		{
+			name:   "zero tile size",
+			modify: func(m *TiledMap) { m.TileWidth, m.TileHeight = 0, 0 },
+			errors: []string{"invalid tile size 0x0"},
+		},
+		{
+			name:   "unknown GID in layer",
+			modify: func(m *TiledMap) { m.Layers[0].Data[3] = 7 },
+			errors: []string{`layer "ground" uses GID 7`},
+		},
+		{
+			name:   "flipped GID is still known",
+			modify: func(m *TiledMap) { m.Layers[0].Data[0] = 2 | 0x80000000 },
+		},
+		{
+			name:   "missing player_start",
+			modify: func(m *TiledMap) { m.Layers[1].Objects = nil },
+			errors: []string{"no player_start object"},
+		},
+		{
+			name:   "object outside the map",
+			modify: func(m *TiledMap) { m.Layers[1].Objects[0].X = -40 },
+			errors: []string{"is outside the 64x64 map"},
+		},
+		{
+			name: "infinite map with objects at negative coordinates",
+			modify: func(m *TiledMap) {
+				m.Infinite = true
+				m.Layers[0].Data = nil
+				m.Layers[0].Chunks = []Chunk{{X: -16, Y: -16, Width: 16, Height: 16, Data: make([]int, 256)}}
+				m.Layers[1].Objects[0].X, m.Layers[1].Objects[0].Y = -100, -100
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeValidTileset(t, dir)
+			m := validTestMap()
+			tt.modify(&m)
+			data, err := json.Marshal(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(dir, "map.json")
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			var errs []string
+			for _, issue := range ValidateMap(path) {
+				if issue.Severity == SeverityError {
+					errs = append(errs, issue.Message)
+				}
+			}
+			if len(errs) != len(tt.errors) {
+				t.Fatalf("got errors %q, want %d matching %q", errs, len(tt.errors), tt.errors)
+			}
+			for i, want := range tt.errors {
+				if !strings.Contains(errs[i], want) {
+					t.Errorf("error %d = %q, want it to contain %q", i, errs[i], want)
+				}
+			}
+		})
+	}
+}